@@ -0,0 +1,93 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+const (
+	MetricNameErrors = "errorcontext.errors"
+	MetricNamePanics = "errorcontext.panics"
+)
+
+// Metrics records counters of errors and recovered panics.
+// The context attributes of the error chain are used as metric dimensions,
+// limited to an allow-list in order to keep the cardinality bounded.
+type Metrics struct {
+	errors            metric.Int64Counter
+	panics            metric.Int64Counter
+	allowedAttributes []attribute.Key
+}
+
+// NewMetrics creates the error and panic counters with the given meter.
+// Only the context attributes with one of the allowedAttributes keys are recorded as metric dimensions.
+func NewMetrics(meter metric.Meter, allowedAttributes ...attribute.Key) (*Metrics, error) {
+	errorCounter, err := meter.Int64Counter(MetricNameErrors,
+		metric.WithDescription("Number of errors."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+	panicCounter, err := meter.Int64Counter(MetricNamePanics,
+		metric.WithDescription("Number of recovered panics."),
+		metric.WithUnit("{panic}"))
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{
+		errors:            errorCounter,
+		panics:            panicCounter,
+		allowedAttributes: slices.Clone(allowedAttributes),
+	}, nil
+}
+
+// RecordError increments the error counter, if err is not nil.
+func (m *Metrics) RecordError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	m.errors.Add(ctx, 1, metric.WithAttributeSet(m.attributes(err)))
+}
+
+// RecordPanic increments the panic counter, if err originates from a recovered panic, see errorcontext.Panic.
+func (m *Metrics) RecordPanic(ctx context.Context, err error) {
+	var p errorcontext.Panic
+	if !errors.As(err, &p) {
+		return
+	}
+	m.panics.Add(ctx, 1, metric.WithAttributeSet(m.attributes(err)))
+}
+
+// attributes returns the allowed context attributes of the error chain.
+// Outer errors take precedence over inner errors for the same attribute key.
+func (m *Metrics) attributes(err error) attribute.Set {
+	var attrs []attribute.KeyValue
+	for _, a := range AsChainContext(err) {
+		if !slices.Contains(m.allowedAttributes, a.Key) {
+			continue
+		}
+		if slices.ContainsFunc(attrs, func(kv attribute.KeyValue) bool { return kv.Key == a.Key }) {
+			continue
+		}
+		attrs = append(attrs, a)
+	}
+	return attribute.NewSet(attrs...)
+}
+
+// CountPanics decorates the error generator of a Recoverer, so that the panic counter is
+// incremented for every recovered panic, with the allowed context attributes of the generated error:
+//
+//	recoverer := errorcontext.NewRecoverer(otlperrorcontext.CountPanics(metrics, otlperrorcontext.FromPanic))
+func CountPanics[T error](m *Metrics, newErrorFunc errorcontext.ErrorGenerator[T]) errorcontext.ErrorGenerator[T] {
+	return func(p errorcontext.Panic) T {
+		err := newErrorFunc(p)
+		m.panics.Add(context.Background(), 1, metric.WithAttributeSet(m.attributes(err)))
+		return err
+	}
+}
@@ -0,0 +1,97 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	m, err := NewMetrics(provider.Meter("test"), "service", "table")
+	require.NoError(t, err)
+
+	m.RecordError(ctx, fmt.Errorf("query failed: %w",
+		NewError(
+			NewError(errors.New("database error"),
+				attribute.String("table", "users"),
+				attribute.String("query", "SELECT 1"),
+				attribute.String("service", "inner")),
+			attribute.String("service", "api"))))
+	m.RecordError(ctx, NewError(errors.New("database error"), attribute.String("service", "api")))
+	m.RecordError(ctx, errors.New("plain error"))
+	m.RecordError(ctx, nil)
+	m.RecordPanic(ctx, errors.New("plain error"))
+
+	r := errorcontext.NewRecoverer(CountPanics(m, FromPanic))
+	panicErr := r.Wrap(func() error {
+		panic("something bad happened")
+	})
+	_ = r.Wrap(func() error {
+		return NewError(errors.New("database error"), attribute.String("service", "api"))
+	})
+	m.RecordPanic(ctx, NewError(panicErr, attribute.String("service", "worker")))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	metricdatatest.AssertEqual(t, metricdata.ScopeMetrics{
+		Scope: rm.ScopeMetrics[0].Scope,
+		Metrics: []metricdata.Metrics{
+			{
+				Name:        MetricNameErrors,
+				Description: "Number of errors.",
+				Unit:        "{error}",
+				Data: metricdata.Sum[int64]{
+					Temporality: metricdata.CumulativeTemporality,
+					IsMonotonic: true,
+					DataPoints: []metricdata.DataPoint[int64]{
+						{
+							Attributes: attribute.NewSet(attribute.String("service", "api"), attribute.String("table", "users")),
+							Value:      1,
+						},
+						{
+							Attributes: attribute.NewSet(attribute.String("service", "api")),
+							Value:      1,
+						},
+						{
+							Attributes: attribute.NewSet(),
+							Value:      1,
+						},
+					},
+				},
+			},
+			{
+				Name:        MetricNamePanics,
+				Description: "Number of recovered panics.",
+				Unit:        "{panic}",
+				Data: metricdata.Sum[int64]{
+					Temporality: metricdata.CumulativeTemporality,
+					IsMonotonic: true,
+					DataPoints: []metricdata.DataPoint[int64]{
+						{
+							Attributes: attribute.NewSet(),
+							Value:      1,
+						},
+						{
+							Attributes: attribute.NewSet(attribute.String("service", "worker")),
+							Value:      1,
+						},
+					},
+				},
+			},
+		},
+	}, rm.ScopeMetrics[0], metricdatatest.IgnoreTimestamp())
+}
@@ -1,9 +1,19 @@
 package otlp
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"strings"
 
+	"github.com/cockroachdb/errors/errbase"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/georgepsarakis/errorcontext"
 )
@@ -14,15 +24,66 @@ type Error struct {
 
 func NewError(err error, context ...attribute.KeyValue) *Error {
 	return &Error{
-		BaseError: errorcontext.NewBaseError[[]attribute.KeyValue](err, context),
+		BaseError: errorcontext.NewBaseError(err, slices.Clip(slices.Clone(context))),
 	}
 }
 
+// NewErrorCtx is similar to NewError, additionally attaching the attributes carried by ctx,
+// see errorcontext.WithFields. Context attributes precede the given attributes.
+func NewErrorCtx(ctx context.Context, err error, fields ...attribute.KeyValue) *Error {
+	return NewError(err, slices.Concat(errorcontext.FieldsFromContext[attribute.KeyValue](ctx), fields)...)
+}
+
 func (e *Error) Context() []attribute.KeyValue {
 	if e == nil {
 		return nil
 	}
-	return e.ContextFields()[:]
+	return e.ContextFields()
+}
+
+// AddContextFields appends the given attributes to the error context.
+// The error context is replaced by a new slice, so that it does not alias the
+// given attributes or slices previously returned by Context.
+func (e *Error) AddContextFields(f ...attribute.KeyValue) {
+	e.UpdateContextFields(func(attrs []attribute.KeyValue) []attribute.KeyValue {
+		return slices.Clip(slices.Concat(attrs, f))
+	})
+}
+
+func (e *Error) MarkAsPanic() *Error {
+	_ = e.BaseError.MarkAsPanic()
+	e.AddContextFields(attribute.Bool("is_panic", true))
+	return e
+}
+
+// Format implements fmt.Formatter, see errorcontext.BaseError.Format.
+func (e *Error) Format(s fmt.State, verb rune) {
+	errbase.FormatError(e, s, verb)
+}
+
+// FormatError implements errbase.Formatter, printing the context attributes as key=value pairs.
+func (e *Error) FormatError(p errbase.Printer) error {
+	return e.FormatKeyValues(p, e.KeyValues())
+}
+
+// KeyValues implements errorcontext.KeyValueError.
+func (e *Error) KeyValues() []errorcontext.KeyValue {
+	return keyValues(e.Context())
+}
+
+// MarshalJSON implements json.Marshaler, see errorcontext.BaseError.MarshalJSON.
+// The context attributes are rendered as the members of the context object.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return e.MarshalKeyValuesJSON(e.KeyValues())
+}
+
+// keyValues converts attributes to key/value pairs.
+func keyValues(attrs []attribute.KeyValue) []errorcontext.KeyValue {
+	kvs := make([]errorcontext.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		kvs = append(kvs, errorcontext.KeyValue{Key: string(a.Key), Value: a.Value.AsInterface()})
+	}
+	return kvs
 }
 
 func AsContext(err error) []attribute.KeyValue {
@@ -35,3 +96,100 @@ func AsContext(err error) []attribute.KeyValue {
 	}
 	return nil
 }
+
+func AsChainContext(err error) []attribute.KeyValue {
+	if err == nil {
+		return nil
+	}
+	var attrs []attribute.KeyValue
+	for _, e := range errorcontext.Collect[*Error](err) {
+		attrs = append(attrs, e.Context()...)
+	}
+	return attrs
+}
+
+// FromPanic converts the panic to an error, with the panic message and stack trace as attributes.
+// Since attribute values cannot be structured, the stack trace is a string slice, see errorcontext.Panic.Stack.
+func FromPanic(p errorcontext.Panic) *Error {
+	e := NewError(
+		p,
+		attribute.String(errorcontext.FieldNamePanicMessage, p.Message),
+		attribute.StringSlice(errorcontext.FieldNamePanicStackTrace, p.Stack()),
+	)
+	if p.ElidedFrames > 0 {
+		e.AddContextFields(attribute.Int(errorcontext.FieldNamePanicElidedFrames, p.ElidedFrames))
+	}
+	if p.ContextErr != nil {
+		e.AddContextFields(attribute.String(errorcontext.FieldNameContextError, p.ContextErr.Error()))
+	}
+	if p.ContextCause != nil {
+		e.AddContextFields(attribute.String(errorcontext.FieldNameContextCause, p.ContextCause.Error()))
+	}
+	return e.MarkAsPanic()
+}
+
+// RecordError records err as an exception event on the span of ctx, with the attributes
+// of the error chain, see AsChainContext, and sets the span status to Error.
+// The exception.type attribute is the type of the recovered panic value for panics, otherwise
+// the type of the innermost error of the chain which is not an errorcontext error, see exceptionType.
+// When err originates from a recovered panic, the panic stack trace is recorded as
+// the exception.stacktrace attribute, instead of the stack trace attribute set by FromPanic.
+//
+// The event is added directly instead of through trace.Span.RecordError, since span implementations
+// set exception.type to the type of err, which is the errorcontext wrapper for all errors.
+func RecordError(ctx context.Context, err error, opts ...trace.EventOption) {
+	if err == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := AsChainContext(err)
+	attrs = append(attrs,
+		semconv.ExceptionType(exceptionType(err)),
+		semconv.ExceptionMessage(err.Error()))
+	var p errorcontext.Panic
+	if errors.As(err, &p) {
+		attrs = slices.DeleteFunc(attrs, func(a attribute.KeyValue) bool {
+			return a.Key == errorcontext.FieldNamePanicStackTrace
+		})
+		attrs = append(attrs, semconv.ExceptionStacktrace(strings.Join(p.Stack(), "\n")))
+	} else if c := trace.NewEventConfig(opts...); c.StackTrace() {
+		attrs = append(attrs, semconv.ExceptionStacktrace(string(debug.Stack())))
+	}
+	span.AddEvent(semconv.ExceptionEventName, append(opts, trace.WithAttributes(attrs...))...)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// errorcontextModule is the import path prefix of the errorcontext error types.
+var errorcontextModule = reflect.TypeFor[errorcontext.Frame]().PkgPath()
+
+// exceptionType returns the type name of the recovered panic value for panics, otherwise of the innermost
+// error of the chain, see errors.Unwrap, which is not an errorcontext error. Type names are formatted
+// as by the OpenTelemetry SDK, e.g. *fs.PathError or io/fs.PathError, except for predeclared types, e.g. string.
+func exceptionType(err error) string {
+	var p errorcontext.Panic
+	if errors.As(err, &p) && p.Value != nil {
+		return typeName(p.Value)
+	}
+	cause := err
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		t := reflect.TypeOf(e)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if !strings.HasPrefix(t.PkgPath(), errorcontextModule) {
+			cause = e
+		}
+	}
+	return typeName(cause)
+}
+
+func typeName(v any) string {
+	t := reflect.TypeOf(v)
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())
+}
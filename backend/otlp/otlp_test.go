@@ -1,11 +1,24 @@
 package otlp
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/georgepsarakis/errorcontext"
 )
 
 func TestError_Context(t *testing.T) {
@@ -20,3 +33,165 @@ func TestError_Context(t *testing.T) {
 		},
 		AsContext(err))
 }
+
+func TestNewErrorCtx(t *testing.T) {
+	ctx := errorcontext.WithFields(context.Background(), attribute.String("request_id", "req-1"))
+
+	err := NewErrorCtx(ctx, errors.New("database error"), attribute.Bool("attr1", true))
+
+	assert.Equal(t,
+		[]attribute.KeyValue{
+			attribute.String("request_id", "req-1"),
+			attribute.Bool("attr1", true),
+		},
+		AsContext(err))
+}
+
+func TestError_Format(t *testing.T) {
+	err := NewError(io.ErrUnexpectedEOF,
+		attribute.Bool("attr1", true),
+		attribute.StringSlice("attr2", []string{"a", "b"}))
+
+	assert.Equal(t, "unexpected EOF", fmt.Sprintf("%v", err))
+	assert.Equal(t, `unexpected EOF
+(1) attr1=true
+  | attr2=[a b]
+Wraps: (2) unexpected EOF
+Error types: (1) *otlp.Error (2) *errors.errorString`, fmt.Sprintf("%+v", err))
+}
+
+func TestError_MarshalJSON(t *testing.T) {
+	err := NewError(errors.New("database error"),
+		attribute.Bool("attr1", true),
+		attribute.Int64Slice("attr2", []int64{1, 2}))
+
+	b, jerr := json.Marshal(err)
+	require.NoError(t, jerr)
+	assert.JSONEq(t, `{
+  "message": "database error",
+  "context": {"attr1": true, "attr2": [1, 2]},
+  "is_panic": false
+}`, string(b))
+}
+
+func TestChainContext(t *testing.T) {
+	err := NewError(
+		fmt.Errorf("wrapped: %w", NewError(errors.New("database error"), attribute.String("attr1", "test1"))),
+		attribute.String("attr2", "test2"))
+
+	assert.Equal(t,
+		[]attribute.KeyValue{
+			attribute.String("attr2", "test2"),
+			attribute.String("attr1", "test1"),
+		},
+		AsChainContext(err))
+	assert.Nil(t, AsChainContext(nil))
+}
+
+func TestFromPanic(t *testing.T) {
+	r := errorcontext.NewRecoverer(FromPanic)
+	err := r.Wrap(func() error {
+		panic(io.ErrUnexpectedEOF)
+	})
+
+	var oerr *Error
+	require.ErrorAs(t, err, &oerr)
+	assert.True(t, oerr.IsPanic())
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	attrs := attribute.NewSet(oerr.Context()...)
+	v, ok := attrs.Value(errorcontext.FieldNamePanicMessage)
+	require.True(t, ok)
+	assert.Equal(t, "panic: unexpected EOF", v.AsString())
+	v, ok = attrs.Value(errorcontext.FieldNamePanicStackTrace)
+	require.True(t, ok)
+	require.NotEmpty(t, v.AsStringSlice())
+	// Frames of the module, including this test, are dropped.
+	assert.True(t, strings.HasPrefix(v.AsStringSlice()[0], "testing.tRunner\n"))
+	v, ok = attrs.Value("is_panic")
+	require.True(t, ok)
+	assert.True(t, v.AsBool())
+}
+
+func TestRecordError(t *testing.T) {
+	newSpan := func(t *testing.T) (context.Context, func() sdktrace.ReadOnlySpan) {
+		t.Helper()
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+		return ctx, func() sdktrace.ReadOnlySpan {
+			span.End()
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			return spans[0]
+		}
+	}
+
+	t.Run("error", func(t *testing.T) {
+		ctx, end := newSpan(t)
+		err := fmt.Errorf("query failed: %w",
+			NewError(errors.New("database error"), attribute.String("table", "users")))
+		RecordError(ctx, err)
+
+		span := end()
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, "query failed: database error", span.Status().Description)
+		require.Len(t, span.Events(), 1)
+		event := span.Events()[0]
+		assert.Equal(t, semconv.ExceptionEventName, event.Name)
+		attrs := attribute.NewSet(event.Attributes...)
+		v, _ := attrs.Value("table")
+		assert.Equal(t, "users", v.AsString())
+		v, _ = attrs.Value(semconv.ExceptionMessageKey)
+		assert.Equal(t, "query failed: database error", v.AsString())
+		v, _ = attrs.Value(semconv.ExceptionTypeKey)
+		assert.Equal(t, "*errors.errorString", v.AsString())
+		assert.False(t, attrs.HasValue(semconv.ExceptionStacktraceKey))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		ctx, end := newSpan(t)
+		r := errorcontext.NewRecoverer(FromPanic)
+		err := r.Wrap(func() error {
+			panic("something bad happened")
+		})
+		RecordError(ctx, err)
+
+		span := end()
+		assert.Equal(t, codes.Error, span.Status().Code)
+		require.Len(t, span.Events(), 1)
+		attrs := attribute.NewSet(span.Events()[0].Attributes...)
+		v, _ := attrs.Value(errorcontext.FieldNamePanicMessage)
+		assert.Equal(t, "panic: something bad happened", v.AsString())
+		v, _ = attrs.Value("is_panic")
+		assert.True(t, v.AsBool())
+		v, _ = attrs.Value(semconv.ExceptionTypeKey)
+		assert.Equal(t, "string", v.AsString())
+		v, _ = attrs.Value(semconv.ExceptionStacktraceKey)
+		assert.True(t, strings.HasPrefix(v.AsString(), "testing.tRunner\n"))
+		assert.False(t, attrs.HasValue(errorcontext.FieldNamePanicStackTrace))
+	})
+
+	t.Run("stack trace option", func(t *testing.T) {
+		ctx, end := newSpan(t)
+		RecordError(ctx, NewError(io.ErrUnexpectedEOF), trace.WithStackTrace(true))
+
+		span := end()
+		require.Len(t, span.Events(), 1)
+		attrs := attribute.NewSet(span.Events()[0].Attributes...)
+		v, _ := attrs.Value(semconv.ExceptionTypeKey)
+		assert.Equal(t, "*errors.errorString", v.AsString())
+		v, _ = attrs.Value(semconv.ExceptionStacktraceKey)
+		assert.Contains(t, v.AsString(), "TestRecordError")
+	})
+
+	t.Run("nil error or no span", func(t *testing.T) {
+		ctx, end := newSpan(t)
+		RecordError(ctx, nil)
+		RecordError(context.Background(), errors.New("database error"))
+
+		span := end()
+		assert.Empty(t, span.Events())
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+}
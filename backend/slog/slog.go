@@ -0,0 +1,148 @@
+package slog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/cockroachdb/errors/errbase"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+type Error struct {
+	*errorcontext.BaseError[[]slog.Attr]
+}
+
+func NewError(err error, context ...slog.Attr) *Error {
+	return &Error{
+		BaseError: errorcontext.NewBaseError(err, slices.Clip(slices.Clone(context))),
+	}
+}
+
+// NewErrorCtx is similar to NewError, additionally attaching the attributes carried by ctx,
+// see errorcontext.WithFields. Context attributes precede the given attributes.
+func NewErrorCtx(ctx context.Context, err error, fields ...slog.Attr) *Error {
+	return NewError(err, slices.Concat(errorcontext.FieldsFromContext[slog.Attr](ctx), fields)...)
+}
+
+func (e *Error) Context() []slog.Attr {
+	return e.ContextFields()
+}
+
+// AddContextFields appends the given attributes to the error context.
+// The error context is replaced by a new slice, so that it does not alias the
+// given attributes or slices previously returned by Context.
+func (e *Error) AddContextFields(f ...slog.Attr) {
+	e.UpdateContextFields(func(attrs []slog.Attr) []slog.Attr {
+		return slices.Clip(slices.Concat(attrs, f))
+	})
+}
+
+// Format implements fmt.Formatter, see errorcontext.BaseError.Format.
+func (e *Error) Format(s fmt.State, verb rune) {
+	errbase.FormatError(e, s, verb)
+}
+
+// FormatError implements errbase.Formatter, printing the context attributes as key=value pairs.
+func (e *Error) FormatError(p errbase.Printer) error {
+	return e.FormatKeyValues(p, e.KeyValues())
+}
+
+// KeyValues implements errorcontext.KeyValueError.
+func (e *Error) KeyValues() []errorcontext.KeyValue {
+	return keyValues(e.Context())
+}
+
+// MarshalJSON implements json.Marshaler, see errorcontext.BaseError.MarshalJSON.
+// The context attributes are rendered as the members of the context object.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return e.MarshalKeyValuesJSON(e.KeyValues())
+}
+
+func (e *Error) MarkAsPanic() *Error {
+	_ = e.BaseError.MarkAsPanic()
+	e.AddContextFields(slog.Bool("is_panic", true))
+	return e
+}
+
+// LogValue implements slog.LogValuer, so that the error message and the attached context
+// are rendered as a group when passed directly to a logger, e.g. slog.Any("error", err).
+// A nil *Error is rendered as "<nil>".
+func (e *Error) LogValue() slog.Value {
+	if e == nil {
+		return slog.StringValue("<nil>")
+	}
+	if e.IsZero() {
+		return slog.GroupValue()
+	}
+	return slog.GroupValue(
+		slog.String("message", e.Error()),
+		slog.Attr{Key: "context", Value: slog.GroupValue(e.Context()...)},
+	)
+}
+
+var _ slog.LogValuer = (*Error)(nil)
+
+func AsContext(err error) []slog.Attr {
+	if err == nil {
+		return nil
+	}
+	var s *Error
+	if errors.As(err, &s) {
+		return s.ContextFields()
+	}
+	return nil
+}
+
+func AsChainContext(err error) []slog.Attr {
+	if err == nil {
+		return nil
+	}
+	var s []slog.Attr
+	for _, e := range errorcontext.Collect[*Error](err) {
+		s = append(s, e.Context()...)
+	}
+	return s
+}
+
+// keyValues converts attributes to key/value pairs. Groups are converted to nested maps.
+func keyValues(attrs []slog.Attr) []errorcontext.KeyValue {
+	kvs := make([]errorcontext.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		kvs = append(kvs, errorcontext.KeyValue{Key: a.Key, Value: attrValue(a.Value)})
+	}
+	return kvs
+}
+
+func attrValue(v slog.Value) any {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	group := make(map[string]any, len(v.Group()))
+	for _, a := range v.Group() {
+		group[a.Key] = attrValue(a.Value)
+	}
+	return group
+}
+
+func FromPanic(p errorcontext.Panic) *Error {
+	e := NewError(
+		p,
+		slog.String(errorcontext.FieldNamePanicMessage, p.Message),
+		slog.Any(errorcontext.FieldNamePanicStackTrace, p.Frames),
+	)
+	if p.ElidedFrames > 0 {
+		e.AddContextFields(slog.Int(errorcontext.FieldNamePanicElidedFrames, p.ElidedFrames))
+	}
+	if p.ContextErr != nil {
+		e.AddContextFields(slog.String(errorcontext.FieldNameContextError, p.ContextErr.Error()))
+	}
+	if p.ContextCause != nil {
+		e.AddContextFields(slog.String(errorcontext.FieldNameContextCause, p.ContextCause.Error()))
+	}
+	return e.MarkAsPanic()
+}
@@ -0,0 +1,250 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+func newLogger(t *testing.T) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	output := bytes.NewBuffer(nil)
+	h := slog.NewJSONHandler(output, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	return slog.New(h), output
+}
+
+func TestError_ContextFields(t *testing.T) {
+	t.Parallel()
+
+	lg, output := newLogger(t)
+
+	err := errors.New("test error")
+	se := NewError(err, slog.String("tag1", "test1"))
+
+	lg.LogAttrs(context.Background(), slog.LevelInfo, "failed to fetch URL",
+		append(se.ContextFields(), slog.Int("attempt", 3))...)
+
+	assert.JSONEq(t,
+		`{"level":"INFO","msg":"failed to fetch URL","tag1":"test1","attempt":3}`,
+		output.String())
+}
+
+func TestError_LogValue(t *testing.T) {
+	t.Parallel()
+
+	lg, output := newLogger(t)
+
+	err := NewError(errors.New("test error"),
+		slog.String("path", "/a/b"),
+		slog.Bool("enabled", true))
+
+	lg.Warn("something failed", slog.Any("error", err))
+
+	assert.JSONEq(t,
+		`{
+  "level": "WARN",
+  "msg": "something failed",
+  "error": {
+    "message": "test error",
+    "context": {"path": "/a/b", "enabled": true}
+  }
+}`, output.String())
+}
+
+func TestError_LogValue_Nil(t *testing.T) {
+	t.Parallel()
+
+	lg, output := newLogger(t)
+
+	var err *Error
+	lg.Warn("something failed", slog.Any("error", err))
+
+	assert.JSONEq(t,
+		`{
+  "level": "WARN",
+  "msg": "something failed",
+  "error": "<nil>"
+}`, output.String())
+}
+
+func TestChainContext(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("test error")
+	se := NewError(err, slog.String("tag1", "test1"))
+	se2 := NewError(se, slog.String("tag2", "test2"))
+
+	assert.Equal(t,
+		[]slog.Attr{
+			slog.String("tag2", "test2"),
+			slog.String("tag1", "test1"),
+		},
+		AsChainContext(se2))
+
+	assert.Nil(t, AsChainContext(nil))
+}
+
+func TestPanicHandler(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	recoverer := errorcontext.NewRecoverer[*Error](FromPanic)
+	require.NotPanics(t, func() {
+		type temp struct {
+			fieldA string
+		}
+		var tmp *temp
+		err = recoverer.Wrap(func() error {
+			tmp.fieldA = "panic"
+			return nil
+		})
+	})
+
+	var pe *Error
+	require.ErrorAs(t, err, &pe)
+	assert.True(t, pe.IsPanic())
+
+	var re runtime.Error
+	require.ErrorAs(t, err, &re)
+
+	attrs := AsContext(err)
+	require.Len(t, attrs, 3)
+
+	assert.Equal(t, "panic", attrs[0].Key)
+	assert.Equal(t,
+		"panic: runtime error: invalid memory address or nil pointer dereference",
+		attrs[0].Value.String())
+	assert.Equal(t, "stack", attrs[1].Key)
+	assert.Equal(t, slog.Bool("is_panic", true), attrs[2])
+
+	lg, output := newLogger(t)
+	lg.Error("recovered", slog.Any("error", err))
+
+	var record struct {
+		Error struct {
+			Message string `json:"message"`
+			Context struct {
+				Stack []errorcontext.Frame `json:"stack"`
+			} `json:"context"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t,
+		"panic: runtime error: invalid memory address or nil pointer dereference",
+		record.Error.Message)
+	assert.True(t,
+		slices.ContainsFunc(record.Error.Context.Stack, func(f errorcontext.Frame) bool {
+			return f.Function == "testing.tRunner"
+		}),
+		output.String())
+	assert.False(t,
+		slices.ContainsFunc(record.Error.Context.Stack, func(f errorcontext.Frame) bool {
+			return strings.HasPrefix(f.Function, "github.com/georgepsarakis/errorcontext")
+		}),
+		output.String())
+}
+
+func TestAsContext(t *testing.T) {
+	type args struct {
+		err error
+	}
+	tests := []struct {
+		name string
+		args args
+		want []slog.Attr
+	}{
+		{
+			name: "nil",
+			args: args{
+				err: nil,
+			},
+			want: nil,
+		},
+		{
+			name: "wrapped errorcontext/slog.Error found",
+			args: args{
+				err: fmt.Errorf("wrapped error: %w",
+					NewError(errors.New("test error"), slog.Bool("is_test", true))),
+			},
+			want: []slog.Attr{slog.Bool("is_test", true)},
+		},
+		{
+			name: "errorcontext/slog.Error not found",
+			args: args{
+				err: fmt.Errorf("wrapped error: %w", errors.New("test error")),
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equalf(t, tt.want, AsContext(tt.args.err), "AsContext(%v)", tt.args.err)
+		})
+	}
+}
+
+func TestNewErrorCtx(t *testing.T) {
+	t.Parallel()
+
+	ctx := errorcontext.WithFields(context.Background(), slog.String("request_id", "req-1"))
+
+	err := NewErrorCtx(ctx, errors.New("test error"), slog.Int("attempt", 3))
+	assert.Equal(t,
+		[]slog.Attr{
+			slog.String("request_id", "req-1"),
+			slog.Int("attempt", 3),
+		},
+		err.Context())
+}
+
+func TestError_Format(t *testing.T) {
+	t.Parallel()
+
+	err := NewError(io.ErrUnexpectedEOF,
+		slog.String("table", "users"),
+		slog.Group("request", slog.String("id", "req-1")))
+
+	assert.Equal(t, "unexpected EOF", fmt.Sprintf("%v", err))
+	assert.Equal(t, `unexpected EOF
+(1) table=users
+  | request=map[id:req-1]
+Wraps: (2) unexpected EOF
+Error types: (1) *slog.Error (2) *errors.errorString`, fmt.Sprintf("%+v", err))
+}
+
+func TestError_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	err := NewError(errors.New("connection reset"),
+		slog.String("host", "db-1"),
+		slog.Group("request", slog.String("id", "req-1")))
+
+	b, jerr := json.Marshal(err)
+	require.NoError(t, jerr)
+	assert.JSONEq(t, `{
+  "message": "connection reset",
+  "context": {"host": "db-1", "request": {"id": "req-1"}},
+  "is_panic": false
+}`, string(b))
+}
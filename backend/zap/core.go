@@ -0,0 +1,115 @@
+package zap
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ContextKeySuffix is appended to the key of error fields to form the key of
+// the error context field injected by the Core returned from NewCore.
+const ContextKeySuffix = "_context"
+
+// NewCore decorates core, so that the context of every error field, e.g. zap.Error(err),
+// is extracted with AsChainContext and logged under the error key suffixed with ContextKeySuffix,
+// e.g. "error_context". Error fields with no context, or with an explicitly logged context field, are left intact.
+// This allows adoption by only changing the logger construction:
+//
+//	logger := zap.New(zaperrorcontext.NewCore(core))
+//	// or, for an existing logger:
+//	logger = logger.WithOptions(zap.WrapCore(zaperrorcontext.NewCore))
+func NewCore(core zapcore.Core) zapcore.Core {
+	return &contextCore{Core: core}
+}
+
+type contextCore struct {
+	zapcore.Core
+}
+
+func (c *contextCore) With(fields []zapcore.Field) zapcore.Core {
+	return &contextCore{Core: c.Core.With(withErrorContext(fields))}
+}
+
+// Check delegates to the decorated core, so that cores which filter entries in Check,
+// e.g. samplers or tees of cores with different levels, keep working. The cores selected
+// by the decorated core are written to through an entryCore, which adds the error context.
+func (c *contextCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked := c.Core.Check(ent, nil)
+	if checked == nil {
+		return ce
+	}
+	return ce.AddCore(ent, &entryCore{Core: c.Core, checked: checked})
+}
+
+func (c *contextCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, withErrorContext(fields))
+}
+
+// entryCore writes a single entry, already checked by the decorated core, along with the error context.
+type entryCore struct {
+	zapcore.Core
+	checked *zapcore.CheckedEntry
+}
+
+func (c *entryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// The Logger annotates the entry, e.g. with the caller and the stack trace, only after Check.
+	c.checked.Entry = ent
+	var errs writeErrors
+	c.checked.ErrorOutput = &errs
+	c.checked.Write(withErrorContext(fields)...)
+	return errs.err
+}
+
+// writeErrors captures the errors which a CheckedEntry reports to its ErrorOutput,
+// so that they are returned to the caller instead.
+//
+// A CheckedEntry does not expose its cores or their write errors, which it only reports
+// by formatting them to its ErrorOutput, as "<time> write error: <error>". Parsing that
+// message is the only way to return the errors to the outer CheckedEntry, which reports them
+// to the ErrorOutput of the Logger, instead of discarding them. Only the message of the
+// original errors is retained.
+type writeErrors struct {
+	err error
+}
+
+func (w *writeErrors) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	if _, after, ok := strings.Cut(msg, "write error: "); ok {
+		msg = after
+	}
+	w.err = errors.Join(w.err, errors.New(msg))
+	return len(p), nil
+}
+
+func (*writeErrors) Sync() error {
+	return nil
+}
+
+// withErrorContext returns fields along with the context fields of any error fields.
+// fields is returned unmodified if there is no error context to add.
+func withErrorContext(fields []zapcore.Field) []zapcore.Field {
+	var extra []zapcore.Field
+	for _, f := range fields {
+		if f.Type != zapcore.ErrorType {
+			continue
+		}
+		err, ok := f.Interface.(error)
+		if !ok {
+			continue
+		}
+		key := f.Key + ContextKeySuffix
+		if slices.ContainsFunc(fields, func(f zapcore.Field) bool { return f.Key == key }) {
+			continue
+		}
+		if ctx := AsChainContext(err); len(ctx) > 0 {
+			extra = append(extra, zap.Dict(key, ctx...))
+		}
+	}
+	if len(extra) == 0 {
+		return fields
+	}
+	return slices.Concat(fields, extra)
+}
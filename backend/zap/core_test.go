@@ -0,0 +1,171 @@
+package zap
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewCore(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("wrapped: %w",
+		NewError(
+			NewError(errors.New("test error"), zap.String("tag1", "test1")),
+			zap.String("tag2", "test2")))
+
+	tests := []struct {
+		name string
+		log  func(logger *zap.Logger)
+		want map[string]any
+	}{
+		{
+			name: "error field",
+			log: func(logger *zap.Logger) {
+				logger.Error("failed", zap.Error(err))
+			},
+			want: map[string]any{
+				"error":         "wrapped: test error",
+				"error_context": map[string]any{"tag2": "test2", "tag1": "test1"},
+			},
+		},
+		{
+			name: "named error field on a child logger",
+			log: func(logger *zap.Logger) {
+				logger.With(zap.NamedError("cause", err)).Info("failed")
+			},
+			want: map[string]any{
+				"cause":         "wrapped: test error",
+				"cause_context": map[string]any{"tag2": "test2", "tag1": "test1"},
+			},
+		},
+		{
+			name: "error without context",
+			log: func(logger *zap.Logger) {
+				logger.Error("failed", zap.Error(errors.New("plain error")))
+			},
+			want: map[string]any{
+				"error": "plain error",
+			},
+		},
+		{
+			name: "explicit error context is retained",
+			log: func(logger *zap.Logger) {
+				logger.Error("failed", zap.Error(err), zap.String("error_context", "explicit"))
+			},
+			want: map[string]any{
+				"error":         "wrapped: test error",
+				"error_context": "explicit",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			core, observedLogs := observer.New(zap.InfoLevel)
+			logger := zap.New(NewCore(core))
+			tt.log(logger)
+
+			logs := observedLogs.All()
+			require.Len(t, logs, 1)
+			fields := logs[0].ContextMap()
+			delete(fields, "errorVerbose")
+			assert.Equal(t, tt.want, fields)
+		})
+	}
+}
+
+func TestNewCore_LevelEnabled(t *testing.T) {
+	t.Parallel()
+
+	core, observedLogs := observer.New(zap.WarnLevel)
+	logger := zap.New(zapcore.NewTee(NewCore(core)))
+	logger.Info("skipped", zap.Error(NewError(errors.New("test error"), zap.String("tag1", "test1"))))
+	logger.Warn("logged")
+
+	logs := observedLogs.All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "logged", logs[0].Message)
+}
+
+func TestNewCore_Sampler(t *testing.T) {
+	t.Parallel()
+
+	core, observedLogs := observer.New(zap.InfoLevel)
+	sampler := zapcore.NewSamplerWithOptions(core, time.Hour, 1, 0)
+	logger := zap.New(NewCore(sampler))
+
+	err := NewError(errors.New("test error"), zap.String("tag1", "test1"))
+	for range 10 {
+		logger.Error("failed", zap.Error(err))
+	}
+
+	logs := observedLogs.All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, map[string]any{"tag1": "test1"}, logs[0].ContextMap()["error_context"])
+}
+
+func TestNewCore_Tee(t *testing.T) {
+	t.Parallel()
+
+	debugCore, debugLogs := observer.New(zap.DebugLevel)
+	errorCore, errorLogs := observer.New(zap.ErrorLevel)
+	logger := zap.New(NewCore(zapcore.NewTee(debugCore, errorCore)))
+
+	err := NewError(errors.New("test error"), zap.String("tag1", "test1"))
+	logger.Debug("debugging", zap.Error(err))
+	logger.Error("failed", zap.Error(err))
+
+	require.Len(t, debugLogs.All(), 2)
+	errorEntries := errorLogs.All()
+	require.Len(t, errorEntries, 1)
+	assert.Equal(t, "failed", errorEntries[0].Message)
+	for _, entry := range append(debugLogs.All(), errorEntries...) {
+		assert.Equal(t, map[string]any{"tag1": "test1"}, entry.ContextMap()["error_context"])
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestNewCore_WriteError(t *testing.T) {
+	t.Parallel()
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(failingWriter{}), zap.InfoLevel)
+	var errorOutput bytes.Buffer
+	logger := zap.New(NewCore(core), zap.ErrorOutput(zapcore.AddSync(&errorOutput)))
+	logger.Info("logged")
+
+	assert.Contains(t, errorOutput.String(), "write error: write failed")
+	assert.Equal(t, 1, strings.Count(errorOutput.String(), "write error"))
+}
+
+func TestNewCore_CallerAndStack(t *testing.T) {
+	t.Parallel()
+
+	core, observedLogs := observer.New(zap.InfoLevel)
+	logger := zap.New(NewCore(core), zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
+	logger.Error("failed", zap.Error(NewError(errors.New("test error"), zap.String("tag1", "test1"))))
+
+	logs := observedLogs.All()
+	require.Len(t, logs, 1)
+	assert.True(t, logs[0].Caller.Defined)
+	assert.Equal(t, "core_test.go", path.Base(logs[0].Caller.File))
+	assert.Contains(t, logs[0].Stack, "TestNewCore_CallerAndStack")
+	assert.Equal(t, map[string]any{"tag1": "test1"}, logs[0].ContextMap()["error_context"])
+}
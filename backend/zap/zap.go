@@ -1,9 +1,15 @@
 package zap
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
 
+	"github.com/cockroachdb/errors/errbase"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/georgepsarakis/errorcontext"
 )
@@ -14,16 +20,93 @@ type Error struct {
 
 func NewError(err error, context ...zap.Field) *Error {
 	return &Error{
-		BaseError: errorcontext.NewBaseError[[]zap.Field](err, context),
+		BaseError: errorcontext.NewBaseError(err, slices.Clip(slices.Clone(context))),
 	}
 }
 
+// NewErrorCtx is similar to NewError, additionally attaching the fields carried by ctx,
+// see errorcontext.WithFields. Context fields precede the given fields.
+func NewErrorCtx(ctx context.Context, err error, fields ...zap.Field) *Error {
+	return NewError(err, slices.Concat(errorcontext.FieldsFromContext[zap.Field](ctx), fields)...)
+}
+
 func (e *Error) Context() []zap.Field {
 	return e.ContextFields()
 }
 
+// AddContextFields appends the given fields to the error context.
+// The error context is replaced by a new slice, so that it does not alias the
+// given fields or slices previously returned by Context.
 func (e *Error) AddContextFields(f ...zap.Field) {
-	e.SetContextFields(append(e.ContextFields(), f...))
+	e.UpdateContextFields(func(fields []zap.Field) []zap.Field {
+		return slices.Clip(slices.Concat(fields, f))
+	})
+}
+
+// Format implements fmt.Formatter, see errorcontext.BaseError.Format.
+func (e *Error) Format(s fmt.State, verb rune) {
+	errbase.FormatError(e, s, verb)
+}
+
+// FormatError implements errbase.Formatter, printing the context fields as key=value pairs.
+func (e *Error) FormatError(p errbase.Printer) error {
+	return e.FormatKeyValues(p, e.KeyValues())
+}
+
+// KeyValues implements errorcontext.KeyValueError.
+func (e *Error) KeyValues() []errorcontext.KeyValue {
+	return keyValues(e.Context())
+}
+
+// MarshalJSON implements json.Marshaler, see errorcontext.BaseError.MarshalJSON.
+// The context fields are rendered as the members of the context object.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return e.MarshalKeyValuesJSON(e.KeyValues())
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler, so that the error is logged as
+// a single nested object with zap.Object, e.g. zap.Object("error", err), with the following keys:
+//   - message: the error message.
+//   - is_panic: whether the error originates from a recovered panic.
+//   - context: the error context fields, including the panic message and stack trace.
+func (e *Error) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", e.Error())
+	enc.AddBool("is_panic", e.IsPanic())
+	return enc.AddObject("context", contextFields(e.Context()))
+}
+
+// Object is similar to zap.Object for errors that are not necessarily an *Error,
+// e.g. errors wrapped by fmt.Errorf. The message of err is logged along with
+// the merged context of the whole error chain, see AsChainContext.
+func Object(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, chainObject{err: err})
+}
+
+type chainObject struct {
+	err error
+}
+
+func (c chainObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	isPanic := false
+	for _, e := range errorcontext.Collect[*Error](c.err) {
+		isPanic = isPanic || e.IsPanic()
+	}
+	enc.AddString("message", c.err.Error())
+	enc.AddBool("is_panic", isPanic)
+	return enc.AddObject("context", contextFields(AsChainContext(c.err)))
+}
+
+// contextFields encodes zap fields as the members of an object.
+type contextFields []zap.Field
+
+func (c contextFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range c {
+		f.AddTo(enc)
+	}
+	return nil
 }
 
 func (e *Error) MarkAsPanic() *Error {
@@ -54,10 +137,91 @@ func AsChainContext(err error) []zap.Field {
 	return z
 }
 
+// AsTreeContext renders the context of all errors within the error tree of err,
+// including all branches of errors created by errors.Join, as an array field.
+// The array contains one object per error, in depth-first order, with the following keys:
+//   - path: the position of the error within the tree, see errorcontext.Node.
+//   - error: the error message.
+//   - context: the error context fields.
+func AsTreeContext(key string, err error) zap.Field {
+	return zap.Array(key, treeContext(errorcontext.CollectTree[*Error](err)))
+}
+
+type treeContext []errorcontext.Node[*Error]
+
+func (t treeContext) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, n := range t {
+		if err := enc.AppendObject(treeNode(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type treeNode errorcontext.Node[*Error]
+
+func (n treeNode) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	err := enc.AddArray("path", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, i := range n.Path {
+			enc.AppendInt(i)
+		}
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+	enc.AddString("error", n.Err.Error())
+	return enc.AddObject("context", contextFields(n.Err.Context()))
+}
+
 func FromPanic(p errorcontext.Panic) *Error {
-	return NewError(
-		errors.New(p.Message),
+	e := NewError(
+		p,
 		zap.String(errorcontext.FieldNamePanicMessage, p.Message),
-		zap.Strings(errorcontext.FieldNamePanicStackTrace, p.Stack),
-	).MarkAsPanic()
+		zap.Array(errorcontext.FieldNamePanicStackTrace, stackFrames(p.Frames)),
+	)
+	if p.ElidedFrames > 0 {
+		e.AddContextFields(zap.Int(errorcontext.FieldNamePanicElidedFrames, p.ElidedFrames))
+	}
+	if p.ContextErr != nil {
+		e.AddContextFields(zap.NamedError(errorcontext.FieldNameContextError, p.ContextErr))
+	}
+	if p.ContextCause != nil {
+		e.AddContextFields(zap.NamedError(errorcontext.FieldNameContextCause, p.ContextCause))
+	}
+	return e.MarkAsPanic()
+}
+
+// keyValues converts zap fields to key/value pairs, with values as encoded by zap.
+func keyValues(fields []zap.Field) []errorcontext.KeyValue {
+	kvs := make([]errorcontext.KeyValue, 0, len(fields))
+	for _, f := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		for _, k := range slices.Sorted(maps.Keys(enc.Fields)) {
+			kvs = append(kvs, errorcontext.KeyValue{Key: k, Value: enc.Fields[k]})
+		}
+	}
+	return kvs
+}
+
+// stackFrames encodes stack frames as an array of {func,file,line} objects.
+type stackFrames []errorcontext.Frame
+
+func (s stackFrames) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, f := range s {
+		if err := enc.AppendObject(stackFrame(f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type stackFrame errorcontext.Frame
+
+func (f stackFrame) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("func", f.Function)
+	enc.AddString("file", f.File)
+	enc.AddInt("line", f.Line)
+	return nil
 }
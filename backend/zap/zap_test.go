@@ -1,9 +1,14 @@
 package zap
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +16,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/georgepsarakis/errorcontext"
@@ -77,6 +83,9 @@ func TestPanicHandler(t *testing.T) {
 	require.ErrorAs(t, err, &pe)
 	assert.True(t, pe.IsPanic())
 
+	var re runtime.Error
+	require.ErrorAs(t, err, &re)
+
 	fields := AsContext(err)
 	require.NotEmpty(t, fields)
 	require.Len(t, fields, 3)
@@ -89,16 +98,26 @@ func TestPanicHandler(t *testing.T) {
 
 	assert.Equal(t, zfStackTrace.Key, "stack")
 
-	assert.NotEmpty(t, zfStackTrace.Interface)
-	b, err := json.Marshal(zfStackTrace.Interface)
-	require.NoError(t, err)
+	enc := zapcore.NewMapObjectEncoder()
+	zfStackTrace.AddTo(enc)
+	require.IsType(t, []any{}, enc.Fields["stack"])
+	frames := enc.Fields["stack"].([]any)
+	require.NotEmpty(t, frames)
 
-	var stackLines []string
-	require.NoError(t, json.Unmarshal(b, &stackLines))
-	assert.Contains(t,
-		stackLines[10],
-		"errorcontext/backend/zap/zap_test.go",
-		strings.Join(stackLines, "\n"))
+	b, err := json.Marshal(frames)
+	require.NoError(t, err)
+	var stack []errorcontext.Frame
+	require.NoError(t, json.Unmarshal(b, &stack))
+	assert.True(t,
+		slices.ContainsFunc(stack, func(f errorcontext.Frame) bool {
+			return f.Function == "testing.tRunner"
+		}),
+		frames)
+	assert.False(t,
+		slices.ContainsFunc(stack, func(f errorcontext.Frame) bool {
+			return strings.HasPrefix(f.Function, "github.com/georgepsarakis/errorcontext")
+		}),
+		string(b))
 }
 
 func TestAsContext(t *testing.T) {
@@ -140,3 +159,264 @@ func TestAsContext(t *testing.T) {
 		})
 	}
 }
+
+func TestFromPanic_Context(t *testing.T) {
+	t.Parallel()
+
+	recoverer := errorcontext.NewRecoverer[*Error](FromPanic)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errors.New("shutting down"))
+
+	err := recoverer.WrapContext(ctx, func(ctx context.Context) error {
+		panic("something bad happened")
+	})
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range AsContext(err) {
+		f.AddTo(enc)
+	}
+	assert.Equal(t, "context canceled", enc.Fields["context_error"])
+	assert.Equal(t, "shutting down", enc.Fields["context_cause"])
+}
+
+func TestFromPanic_ElidedFrames(t *testing.T) {
+	t.Parallel()
+
+	err := FromPanic(errorcontext.Panic{
+		Message:      "panic: something bad happened",
+		Frames:       []errorcontext.Frame{{Function: "main.main", File: "/app/main.go", Line: 5}},
+		ElidedFrames: 4,
+	})
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range err.Context() {
+		f.AddTo(enc)
+	}
+	assert.Equal(t, map[string]any{
+		"panic": "panic: something bad happened",
+		"stack": []any{
+			map[string]any{"func": "main.main", "file": "/app/main.go", "line": 5},
+		},
+		"stack_elided_frames": int64(4),
+		"is_panic":            true,
+	}, enc.Fields)
+}
+
+func TestNewErrorCtx(t *testing.T) {
+	t.Parallel()
+
+	ctx := errorcontext.WithFields(context.Background(),
+		zap.String("request_id", "req-1"),
+		zap.String("tenant", "acme"))
+
+	err := NewErrorCtx(ctx, errors.New("test error"), zap.Int("attempt", 3))
+	assert.Equal(t,
+		[]zap.Field{
+			zap.String("request_id", "req-1"),
+			zap.String("tenant", "acme"),
+			zap.Int("attempt", 3),
+		},
+		err.Context())
+
+	assert.Equal(t,
+		[]zap.Field{zap.Int("attempt", 3)},
+		NewErrorCtx(context.Background(), errors.New("test error"), zap.Int("attempt", 3)).Context())
+}
+
+func TestError_Format(t *testing.T) {
+	t.Parallel()
+
+	err := NewError(fmt.Errorf("query failed: %w", io.ErrUnexpectedEOF),
+		zap.String("table", "users"),
+		zap.Int("attempt", 3))
+
+	assert.Equal(t, "query failed: unexpected EOF", fmt.Sprintf("%v", err))
+	assert.Equal(t, `query failed: unexpected EOF
+(1) table=users
+  | attempt=3
+Wraps: (2) query failed
+Wraps: (3) unexpected EOF
+Error types: (1) *zap.Error (2) *fmt.wrapError (3) *errors.errorString`, fmt.Sprintf("%+v", err))
+
+	core, observedLogs := observer.New(zap.InfoLevel)
+	zap.New(core).Warn("something failed", zap.Error(err))
+	fields := observedLogs.All()[0].ContextMap()
+	assert.Equal(t, "query failed: unexpected EOF", fields["error"])
+	assert.Equal(t, fmt.Sprintf("%+v", err), fields["errorVerbose"])
+}
+
+func TestError_Format_Panic(t *testing.T) {
+	t.Parallel()
+
+	err := FromPanic(errorcontext.Panic{
+		Message: "panic: something bad happened",
+		Value:   "something bad happened",
+		Frames:  []errorcontext.Frame{{Function: "main.main", File: "/app/main.go", Line: 5}},
+	})
+	assert.Equal(t, `panic: something bad happened
+(1) panic=panic: something bad happened
+  | is_panic=true
+Wraps: (2) panic: something bad happened
+  | main.main
+  | 	/app/main.go:5
+Error types: (1) *zap.Error (2) errorcontext.Panic`, fmt.Sprintf("%+v", err))
+}
+
+func TestError_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	inner := NewError(errors.New("connection reset"), zap.String("host", "db-1"))
+	err := NewError(fmt.Errorf("query failed: %w", inner),
+		zap.String("table", "users"),
+		zap.Int("attempt", 3),
+		zap.Strings("columns", []string{"id", "name"}))
+
+	b, jerr := json.Marshal(err)
+	require.NoError(t, jerr)
+	assert.JSONEq(t, `{
+  "message": "query failed: connection reset",
+  "context": {"table": "users", "attempt": 3, "columns": ["id", "name"]},
+  "is_panic": false,
+  "cause": {
+    "message": "connection reset",
+    "context": {"host": "db-1"},
+    "is_panic": false
+  }
+}`, string(b))
+
+	ue, jerr := errorcontext.Unmarshal(b)
+	require.NoError(t, jerr)
+	assert.EqualError(t, ue, "query failed: connection reset")
+	assert.Equal(t, "users", ue.ContextFields()["table"])
+}
+
+func TestAsTreeContext(t *testing.T) {
+	t.Parallel()
+
+	// Note: errors.Join of cockroachdb/errors wraps the joined errors with a stack trace.
+	err := NewError(
+		errors.Join(
+			NewError(errors.New("branch 1"), zap.String("tag1", "test1")),
+			fmt.Errorf("branch 2: %w", NewError(errors.New("test error"), zap.String("tag2", "test2"))),
+		),
+		zap.String("tag0", "test0"))
+
+	assert.Equal(t,
+		[]zap.Field{
+			zap.String("tag0", "test0"),
+			zap.String("tag1", "test1"),
+			zap.String("tag2", "test2"),
+		},
+		AsChainContext(err))
+
+	enc := zapcore.NewMapObjectEncoder()
+	AsTreeContext("errors", err).AddTo(enc)
+	assert.Equal(t, []any{
+		map[string]any{
+			"path":    []any{},
+			"error":   "branch 1\nbranch 2: test error",
+			"context": map[string]any{"tag0": "test0"},
+		},
+		map[string]any{
+			"path":    []any{0, 0, 0},
+			"error":   "branch 1",
+			"context": map[string]any{"tag1": "test1"},
+		},
+		map[string]any{
+			"path":    []any{0, 0, 1, 0},
+			"error":   "test error",
+			"context": map[string]any{"tag2": "test2"},
+		},
+	}, enc.Fields["errors"])
+}
+
+func TestError_AddContextFields(t *testing.T) {
+	t.Parallel()
+
+	t.Run("does not alias the given fields", func(t *testing.T) {
+		t.Parallel()
+
+		fields := make([]zap.Field, 1, 4)
+		fields[0] = zap.String("a", "b")
+		err := NewError(errors.New("test error"), fields...)
+		err.AddContextFields(zap.String("c", "d"))
+		_ = append(fields, zap.String("e", "f"))
+
+		assert.Equal(t, []zap.Field{zap.String("a", "b"), zap.String("c", "d")}, err.Context())
+	})
+
+	t.Run("does not modify previously returned context", func(t *testing.T) {
+		t.Parallel()
+
+		err := NewError(errors.New("test error"), zap.String("a", "b"))
+		before := err.Context()
+		err.AddContextFields(zap.String("c", "d"))
+		_ = append(before, zap.String("e", "f"))
+
+		assert.Equal(t, []zap.Field{zap.String("a", "b")}, before)
+		assert.Equal(t, []zap.Field{zap.String("a", "b"), zap.String("c", "d")}, err.Context())
+	})
+
+	t.Run("concurrent annotate and log", func(t *testing.T) {
+		t.Parallel()
+
+		logger := zap.New(zapcore.NewCore(
+			zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+			zapcore.AddSync(io.Discard),
+			zap.InfoLevel))
+		err := NewError(errors.New("test error"), zap.String("a", "b"))
+
+		var wg sync.WaitGroup
+		for i := range 50 {
+			wg.Go(func() {
+				err.AddContextFields(zap.Int(fmt.Sprintf("field%d", i), i))
+			})
+			wg.Go(func() {
+				logger.Error("failed", zap.Dict("error_context", AsContext(err)...), zap.Error(err))
+			})
+		}
+		wg.Wait()
+
+		assert.Len(t, err.Context(), 51)
+	})
+}
+
+func TestError_MarshalLogObject(t *testing.T) {
+	t.Parallel()
+
+	err := NewError(errors.New("query failed"), zap.String("table", "users"), zap.Int("attempt", 3))
+
+	enc := zapcore.NewMapObjectEncoder()
+	zap.Object("error", err).AddTo(enc)
+	assert.Equal(t, map[string]any{
+		"message":  "query failed",
+		"is_panic": false,
+		"context":  map[string]any{"table": "users", "attempt": int64(3)},
+	}, enc.Fields["error"])
+}
+
+func TestObject(t *testing.T) {
+	t.Parallel()
+
+	r := errorcontext.NewRecoverer(FromPanic)
+	perr := r.Wrap(func() error {
+		panic("something bad happened")
+	})
+	err := fmt.Errorf("handler failed: %w", NewError(perr, zap.String("request_id", "abc")))
+
+	enc := zapcore.NewMapObjectEncoder()
+	Object("error", err).AddTo(enc)
+	obj, ok := enc.Fields["error"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "handler failed: panic: something bad happened", obj["message"])
+	assert.Equal(t, true, obj["is_panic"])
+	ctx, ok := obj["context"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "abc", ctx["request_id"])
+	assert.Equal(t, "panic: something bad happened", ctx[errorcontext.FieldNamePanicMessage])
+	assert.NotEmpty(t, ctx[errorcontext.FieldNamePanicStackTrace])
+
+	enc = zapcore.NewMapObjectEncoder()
+	Object("error", nil).AddTo(enc)
+	assert.Empty(t, enc.Fields)
+}
@@ -0,0 +1,60 @@
+package zerolog
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/rs/zerolog"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+// ErrorMarshalFunc returns a function suitable for zerolog.ErrorMarshalFunc.
+// Errors containing an *Error in their chain are rendered as an object with the following keys:
+//   - message: the error message.
+//   - context: the merged context fields of the error chain, where outer errors take precedence.
+//
+// All other errors are passed to next, if set, otherwise they are rendered by zerolog as usual.
+func ErrorMarshalFunc(next func(error) any) func(error) any {
+	return func(err error) any {
+		chain := errorcontext.Collect[*Error](err)
+		if len(chain) == 0 {
+			if next == nil {
+				return err
+			}
+			return next(err)
+		}
+		return chainObject{err: err, chain: chain}
+	}
+}
+
+// InstallErrorMarshalFunc sets zerolog.ErrorMarshalFunc, so that Event.Err renders the context of errors
+// without calling AsContext, chaining to the current zerolog.ErrorMarshalFunc for all other errors.
+// The returned function restores the previous zerolog.ErrorMarshalFunc.
+// Similarly to zerolog.ErrorStackMarshaler, it is meant to be called once during program initialization:
+//
+//	func init() {
+//		zerologerrorcontext.InstallErrorMarshalFunc()
+//	}
+func InstallErrorMarshalFunc() (restore func()) {
+	prev := zerolog.ErrorMarshalFunc
+	zerolog.ErrorMarshalFunc = ErrorMarshalFunc(prev)
+	return func() {
+		zerolog.ErrorMarshalFunc = prev
+	}
+}
+
+type chainObject struct {
+	err   error
+	chain []*Error
+}
+
+// MarshalZerologObject implements zerolog.LogObjectMarshaler.
+// The error chain is not rendered with Event.Err, since it would recursively invoke zerolog.ErrorMarshalFunc.
+func (c chainObject) MarshalZerologObject(e *zerolog.Event) {
+	fields := make(map[string]any)
+	for _, ce := range slices.Backward(c.chain) {
+		maps.Copy(fields, ce.ContextFields())
+	}
+	e.Str("message", c.err.Error()).Dict("context", zerolog.Dict().Fields(fields))
+}
@@ -0,0 +1,76 @@
+package zerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+func TestErrorMarshalFunc(t *testing.T) {
+	t.Parallel()
+
+	var output bytes.Buffer
+	lg := zerolog.New(&output)
+
+	marshal := ErrorMarshalFunc(func(err error) any {
+		return "next: " + err.Error()
+	})
+	err := fmt.Errorf("wrapped: %w",
+		NewError(
+			NewError(errors.New("test error"), map[string]any{"a": "b", "c": "d"}),
+			map[string]any{"a": "outer"}))
+
+	lg.Log().Object("error", marshal(err).(zerolog.LogObjectMarshaler)).Send()
+	lg.Log().Str("error", marshal(errors.New("plain error")).(string)).Send()
+
+	dec := json.NewDecoder(&output)
+	var record map[string]any
+	require.NoError(t, dec.Decode(&record))
+	assert.Equal(t, map[string]any{
+		"error": map[string]any{
+			"message": "wrapped: test error",
+			"context": map[string]any{"a": "outer", "c": "d"},
+		},
+	}, record)
+	record = nil
+	require.NoError(t, dec.Decode(&record))
+	assert.Equal(t, map[string]any{"error": "next: plain error"}, record)
+
+	assert.Nil(t, ErrorMarshalFunc(nil)(nil))
+}
+
+func TestInstallErrorMarshalFunc(t *testing.T) {
+	lg, output := newLogger(t)
+
+	restore := InstallErrorMarshalFunc()
+	r := errorcontext.NewRecoverer(FromPanic)
+	err := r.Wrap(func() error {
+		panic("something bad happened")
+	})
+	lg.Error().Err(err).Send()
+	restore()
+
+	var record struct {
+		Error struct {
+			Message string         `json:"message"`
+			Context map[string]any `json:"context"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, "panic: something bad happened", record.Error.Message)
+	assert.Equal(t, true, record.Error.Context["is_panic"])
+	assert.Equal(t, "panic: something bad happened", record.Error.Context[errorcontext.FieldNamePanicMessage])
+	assert.NotEmpty(t, record.Error.Context[errorcontext.FieldNamePanicStackTrace])
+
+	output.Reset()
+	lg.Error().Err(err).Send()
+	assert.JSONEq(t, `{"level":"error","error":"panic: something bad happened","time":"2025-01-02T11:22:33Z"}`, output.String())
+}
@@ -1,40 +1,98 @@
 package zerolog
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
 
+	"github.com/cockroachdb/errors/errbase"
 	"github.com/rs/zerolog"
 
 	"github.com/georgepsarakis/errorcontext"
 )
 
+// Error stores the context fields in a reusable form, since zerolog events are pooled
+// and can only be consumed once. A fresh event is materialized on every Context call,
+// so that the same error can be logged multiple times.
 type Error struct {
-	*errorcontext.BaseError[*zerolog.Event]
+	*errorcontext.BaseError[map[string]any]
 }
 
-func NewError(err error, dict *zerolog.Event) *Error {
-	if dict == nil {
-		dict = zerolog.Dict()
-	}
-	dict = dict.Stack().Err(err)
-	b := errorcontext.NewBaseError[*zerolog.Event](
+func NewError(err error, fields map[string]any) *Error {
+	b := errorcontext.NewBaseError(
 		err,
-		dict,
+		maps.Clone(fields),
 	)
 	return &Error{
 		BaseError: b,
 	}
 }
 
+// NewErrorCtx is similar to NewError, additionally attaching the fields carried by ctx,
+// see errorcontext.WithFields. Fields are carried as map[string]any values:
+//
+//	ctx = errorcontext.WithFields(ctx, map[string]any{"request_id": requestID})
+//
+// The given fields take precedence over context fields with the same key.
+func NewErrorCtx(ctx context.Context, err error, fields map[string]any) *Error {
+	merged := make(map[string]any)
+	for _, f := range errorcontext.FieldsFromContext[map[string]any](ctx) {
+		maps.Copy(merged, f)
+	}
+	maps.Copy(merged, fields)
+	return NewError(err, merged)
+}
+
+// Context materializes a new event containing the context fields, along with
+// the original error and its stack trace, if zerolog.ErrorStackMarshaler is set.
 func (e *Error) Context() *zerolog.Event {
-	if e == nil {
+	if e == nil || e.IsZero() {
 		return zerolog.Dict()
 	}
-	return e.ContextFields()
+	return zerolog.Dict().Fields(e.ContextFields()).Stack().Err(e.Unwrap())
+}
+
+// Format implements fmt.Formatter, see errorcontext.BaseError.Format.
+func (e *Error) Format(s fmt.State, verb rune) {
+	errbase.FormatError(e, s, verb)
+}
+
+// FormatError implements errbase.Formatter, printing the context fields as key=value pairs.
+func (e *Error) FormatError(p errbase.Printer) error {
+	return e.FormatKeyValues(p, e.KeyValues())
+}
+
+// KeyValues implements errorcontext.KeyValueError.
+func (e *Error) KeyValues() []errorcontext.KeyValue {
+	return keyValues(e.ContextFields())
+}
+
+// MarshalJSON implements json.Marshaler, see errorcontext.BaseError.MarshalJSON.
+// The context fields are rendered as the members of the context object.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return e.MarshalKeyValuesJSON(e.KeyValues())
+}
+
+// keyValues converts the fields to key/value pairs, sorted by key.
+func keyValues(fields map[string]any) []errorcontext.KeyValue {
+	kvs := make([]errorcontext.KeyValue, 0, len(fields))
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		kvs = append(kvs, errorcontext.KeyValue{Key: k, Value: fields[k]})
+	}
+	return kvs
 }
 
+// AddContextFields merges the given fields into the error context.
+// The error context is replaced by a new map, so that events materialized concurrently are not affected.
 func (e *Error) AddContextFields(f map[string]any) {
-	e.SetContextFields(e.ContextFields().Fields(f))
+	e.UpdateContextFields(func(fields map[string]any) map[string]any {
+		merged := make(map[string]any, len(fields)+len(f))
+		maps.Copy(merged, fields)
+		maps.Copy(merged, f)
+		return merged
+	})
 }
 
 func (e *Error) MarkAsPanic() *Error {
@@ -49,7 +107,7 @@ func AsContext(err error) *zerolog.Event {
 	}
 	var z *Error
 	if errors.As(err, &z) {
-		return z.ContextFields()
+		return z.Context()
 	}
 	return nil
 }
@@ -74,14 +132,34 @@ func AsChainContext(err error) []ErrorEventPair {
 	return z
 }
 
+// AsTreeContext renders the context of all errors within the error tree of err,
+// including all branches of errors created by errors.Join, as an array.
+// The array contains one object per error, in depth-first order, with the following keys:
+//   - path: the position of the error within the tree, see errorcontext.Node.
+//   - context: the error context event.
+func AsTreeContext(err error) *zerolog.Array {
+	arr := zerolog.Arr()
+	for _, n := range errorcontext.CollectTree[*Error](err) {
+		arr = arr.Dict(zerolog.Dict().
+			Ints("path", n.Path).
+			Dict("context", n.Err.Context()))
+	}
+	return arr
+}
+
 func FromPanic(p errorcontext.Panic) *Error {
-	return NewError(
-		errors.New(p.Message),
-		zerolog.Dict().Fields(
-			map[string]any{
-				errorcontext.FieldNamePanicMessage:    p.Message,
-				errorcontext.FieldNamePanicStackTrace: p.Stack,
-			},
-		),
-	).MarkAsPanic()
+	fields := map[string]any{
+		errorcontext.FieldNamePanicMessage:    p.Message,
+		errorcontext.FieldNamePanicStackTrace: p.Frames,
+	}
+	if p.ElidedFrames > 0 {
+		fields[errorcontext.FieldNamePanicElidedFrames] = p.ElidedFrames
+	}
+	if p.ContextErr != nil {
+		fields[errorcontext.FieldNameContextError] = p.ContextErr.Error()
+	}
+	if p.ContextCause != nil {
+		fields[errorcontext.FieldNameContextCause] = p.ContextCause.Error()
+	}
+	return NewError(p, fields).MarkAsPanic()
 }
@@ -2,8 +2,14 @@ package zerolog
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -30,15 +36,51 @@ func newLogger(t *testing.T) (zerolog.Logger, *bytes.Buffer) {
 	return zerolog.New(output).With().Timestamp().Logger(), output
 }
 
+// normalizeStacks keeps only the pkgerrors stack frames of this file in a log record and removes
+// their line numbers, so that assertions depend neither on the position of the code in the source
+// files nor on the Go version and architecture the tests run on.
+func normalizeStacks(t *testing.T, record string) string {
+	t.Helper()
+
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(record), &v))
+	var walk func(v any)
+	walk = func(v any) {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		for key, value := range m {
+			if frames, ok := value.([]any); ok && key == "stack" {
+				var own []any
+				for _, f := range frames {
+					frame := f.(map[string]any)
+					if frame["source"] == "zerolog_test.go" {
+						delete(frame, "line")
+						own = append(own, frame)
+					}
+				}
+				m[key] = own
+				continue
+			}
+			walk(value)
+		}
+	}
+	walk(v)
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
+
 func TestError_Context(t *testing.T) {
 	lg, output := newLogger(t)
 	err := errors.New("something went really wrong")
 
 	ze := NewError(
 		err,
-		zerolog.Dict().Str("a", "b"))
+		map[string]any{"a": "b"})
 
-	lg.Error().Dict("context", ze.ContextFields()).Send()
+	lg.Error().Dict("context", ze.Context()).Send()
 	assert.JSONEq(t,
 		`{
   "level": "error",
@@ -47,32 +89,21 @@ func TestError_Context(t *testing.T) {
     "stack": [
       {
         "func": "TestError_Context",
-        "line": "35",
         "source": "zerolog_test.go"
-      },
-      {
-        "func": "tRunner",
-        "line": "1934",
-        "source": "testing.go"
-      },
-      {
-        "func": "goexit",
-        "line": "1268",
-        "source": "asm_arm64.s"
       }
     ],
     "error": "something went really wrong"
   },
   "time": "2025-01-02T11:22:33Z"
-}`, output.String())
+}`, normalizeStacks(t, output.String()))
 }
 
 func TestChainContext(t *testing.T) {
 	lg, output := newLogger(t)
 
 	baseErr := errors.New("something went really wrong")
-	ze1 := NewError(baseErr, zerolog.Dict().Str("a", "b"))
-	ze2 := NewError(ze1, zerolog.Dict().Str("c", "d"))
+	ze1 := NewError(baseErr, map[string]any{"a": "b"})
+	ze2 := NewError(ze1, map[string]any{"c": "d"})
 
 	ev := lg.Info()
 	for i, c := range AsChainContext(ze2) {
@@ -87,18 +118,7 @@ func TestChainContext(t *testing.T) {
 			"stack": [
 			  {
 				"func": "TestChainContext",
-				"line": "73",
 				"source": "zerolog_test.go"
-			  },
-			  {
-				"func": "tRunner",
-				"line": "1934",
-				"source": "testing.go"
-			  },
-			  {
-				"func": "goexit",
-				"line": "1268",
-				"source": "asm_arm64.s"
 			  }
 			],
 			"error": "something went really wrong"
@@ -108,25 +128,14 @@ func TestChainContext(t *testing.T) {
 			"stack": [
 			  {
 				"func": "TestChainContext",
-				"line": "73",
 				"source": "zerolog_test.go"
-			  },
-			  {
-				"func": "tRunner",
-				"line": "1934",
-				"source": "testing.go"
-			  },
-			  {
-				"func": "goexit",
-				"line": "1268",
-				"source": "asm_arm64.s"
 			  }
 			],
 			"error": "something went really wrong"
 		  },
 		  "time": "2025-01-02T11:22:33Z"
 		}
-	`, output.String())
+	`, normalizeStacks(t, output.String()))
 }
 
 func TestPanicHandler(t *testing.T) {
@@ -149,8 +158,11 @@ func TestPanicHandler(t *testing.T) {
 	var ze *Error
 	require.ErrorAs(t, err, &ze)
 
+	var re runtime.Error
+	require.ErrorAs(t, err, &re)
+
 	lg, output := newLogger(t)
-	lg.Error().Dict("context", ze.ContextFields()).Send()
+	lg.Error().Dict("context", ze.Context()).Send()
 
 	var c map[string]any
 
@@ -166,11 +178,153 @@ func TestPanicHandler(t *testing.T) {
 
 	stack := ctx["stack"].([]any)
 
-	assert.IsType(t, stack[0], "")
-	assert.Contains(t, stack[7].(string), "panic")
-	assert.Contains(t, stack[10].(string), "errorcontext/backend/zerolog/zerolog_test.go")
+	require.IsType(t, map[string]any{}, stack[0])
+	assert.Contains(t, stack[0].(map[string]any), "func")
+	assert.True(t,
+		slices.ContainsFunc(stack, func(f any) bool {
+			return f.(map[string]any)["func"] == "testing.tRunner"
+		}),
+		output.String())
+	assert.False(t,
+		slices.ContainsFunc(stack, func(f any) bool {
+			return strings.HasPrefix(f.(map[string]any)["func"].(string), "github.com/georgepsarakis/errorcontext")
+		}),
+		output.String())
 
 	msg := ctx["panic"]
 
 	assert.Equal(t, msg, "panic: runtime error: invalid memory address or nil pointer dereference")
 }
+
+func TestNewErrorCtx(t *testing.T) {
+	lg, output := newLogger(t)
+
+	ctx := errorcontext.WithFields(context.Background(), map[string]any{"request_id": "req-1"})
+	ze := NewErrorCtx(ctx, errors.New("something went really wrong"), map[string]any{"a": "b"})
+
+	lg.Error().Dict("context", ze.Context()).Send()
+
+	var record struct {
+		Context map[string]any `json:"context"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, "b", record.Context["a"])
+	assert.Equal(t, "req-1", record.Context["request_id"])
+	assert.Equal(t, "something went really wrong", record.Context["error"])
+}
+
+func TestError_Format(t *testing.T) {
+	t.Parallel()
+
+	err := NewError(io.ErrUnexpectedEOF, map[string]any{"b": 1, "a": "b"})
+
+	assert.Equal(t, "unexpected EOF", fmt.Sprintf("%v", err))
+	assert.Equal(t, `unexpected EOF
+(1) a=b
+  | b=1
+Wraps: (2) unexpected EOF
+Error types: (1) *zerolog.Error (2) *errors.errorString`, fmt.Sprintf("%+v", err))
+}
+
+func TestError_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	inner := NewError(errors.New("something went really wrong"), map[string]any{"a": "b"})
+	err := NewError(fmt.Errorf("wrapped: %w", inner), map[string]any{"c": 1})
+
+	b, jerr := json.Marshal(err)
+	require.NoError(t, jerr)
+	assert.JSONEq(t, `{
+  "message": "wrapped: something went really wrong",
+  "context": {"c": 1},
+  "is_panic": false,
+  "cause": {
+    "message": "something went really wrong",
+    "context": {"a": "b"},
+    "is_panic": false
+  }
+}`, string(b))
+}
+
+func TestError_Context_Reusable(t *testing.T) {
+	lg, output := newLogger(t)
+
+	err := NewError(errors.New("something went really wrong"), map[string]any{"a": "b"})
+
+	logContext := func() map[string]any {
+		output.Reset()
+		lg.Error().Dict("context", AsContext(err)).Send()
+		var record struct {
+			Context map[string]any `json:"context"`
+		}
+		require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+		return record.Context
+	}
+
+	first := logContext()
+	assert.Equal(t, "b", first["a"])
+	assert.Equal(t, "something went really wrong", first["error"])
+	assert.Equal(t, first, logContext())
+
+	err.AddContextFields(map[string]any{"c": "d"})
+	third := logContext()
+	assert.Equal(t, "b", third["a"])
+	assert.Equal(t, "d", third["c"])
+	assert.Equal(t, "something went really wrong", third["error"])
+}
+
+func TestNewError_CopiesFields(t *testing.T) {
+	t.Parallel()
+
+	fields := map[string]any{"a": "b"}
+	err := NewError(errors.New("something went really wrong"), fields)
+	fields["c"] = "d"
+
+	assert.Equal(t, map[string]any{"a": "b"}, err.ContextFields())
+}
+
+func TestAsTreeContext(t *testing.T) {
+	lg, output := newLogger(t)
+
+	err := errors.Join(
+		NewError(errors.New("branch 1"), map[string]any{"a": "b"}),
+		NewError(errors.New("branch 2"), map[string]any{"c": "d"}),
+	)
+	lg.Error().Array("errors", AsTreeContext(err)).Send()
+
+	var record struct {
+		Errors []struct {
+			Path    []int          `json:"path"`
+			Context map[string]any `json:"context"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	require.Len(t, record.Errors, 2)
+	// Note: errors.Join of cockroachdb/errors wraps the joined errors with a stack trace.
+	assert.Equal(t, []int{0, 0}, record.Errors[0].Path)
+	assert.Equal(t, "b", record.Errors[0].Context["a"])
+	assert.Equal(t, "branch 1", record.Errors[0].Context["error"])
+	assert.Equal(t, []int{0, 1}, record.Errors[1].Path)
+	assert.Equal(t, "d", record.Errors[1].Context["c"])
+	assert.Equal(t, "branch 2", record.Errors[1].Context["error"])
+}
+
+func TestError_AddContextFields_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	lg := zerolog.New(io.Discard)
+	err := NewError(errors.New("something went really wrong"), map[string]any{"a": "b"})
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			err.AddContextFields(map[string]any{fmt.Sprintf("field%d", i): i})
+		})
+		wg.Go(func() {
+			lg.Error().Dict("context", AsContext(err)).Send()
+		})
+	}
+	wg.Wait()
+
+	assert.Len(t, err.ContextFields(), 51)
+}
@@ -0,0 +1,24 @@
+package errorcontext
+
+import (
+	"context"
+	"slices"
+)
+
+type fieldsContextKey[T any] struct{}
+
+// WithFields returns a copy of ctx carrying the given error context fields,
+// in addition to any fields of the same type already carried by ctx.
+// Request-scoped fields, e.g. the request ID, can thus be set once at the service layer
+// and are merged into errors created by the backend NewErrorCtx constructors.
+//
+//	ctx = errorcontext.WithFields(ctx, zap.String("request_id", requestID))
+func WithFields[T any](ctx context.Context, fields ...T) context.Context {
+	return context.WithValue(ctx, fieldsContextKey[T]{}, slices.Concat(FieldsFromContext[T](ctx), fields))
+}
+
+// FieldsFromContext returns the error context fields of type T carried by ctx, in insertion order.
+func FieldsFromContext[T any](ctx context.Context) []T {
+	fields, _ := ctx.Value(fieldsContextKey[T]{}).([]T)
+	return fields
+}
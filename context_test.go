@@ -0,0 +1,27 @@
+package errorcontext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFields(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	assert.Nil(t, FieldsFromContext[string](ctx))
+
+	ctx = WithFields(ctx, "request_id", "tenant")
+	child := WithFields(ctx, "user")
+
+	assert.Equal(t, []string{"request_id", "tenant"}, FieldsFromContext[string](ctx))
+	assert.Equal(t, []string{"request_id", "tenant", "user"}, FieldsFromContext[string](child))
+	assert.Nil(t, FieldsFromContext[int](child))
+
+	// Sibling contexts do not share fields.
+	sibling := WithFields(ctx, "other")
+	assert.Equal(t, []string{"request_id", "tenant", "other"}, FieldsFromContext[string](sibling))
+	assert.Equal(t, []string{"request_id", "tenant", "user"}, FieldsFromContext[string](child))
+}
@@ -1,15 +1,23 @@
 package errorcontext
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"runtime/debug"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors/errbase"
 )
 
+// BaseError is the base type of errors carrying context.
+// It is safe for concurrent use: the context fields and panic marker are guarded by a lock.
+// Context values of reference types, e.g. slices and maps, should be treated as immutable
+// and be replaced instead of modified in-place, see UpdateContextFields.
 type BaseError[T any] struct {
+	mu            sync.RWMutex
 	originalErr   error
 	contextFields T
 	isPanic       bool
@@ -39,15 +47,93 @@ func (e *BaseError[T]) IsZero() bool {
 }
 
 func (e *BaseError[T]) ContextFields() T {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	return e.contextFields
 }
 
 // SetContextFields is a setter that replaces the attached error context.
 func (e *BaseError[T]) SetContextFields(f T) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.contextFields = f
 }
 
+// UpdateContextFields atomically replaces the attached error context with the result of fn,
+// which receives the current error context. fn must not modify the current error context in-place,
+// since it may be concurrently read, e.g. by a logger.
+func (e *BaseError[T]) UpdateContextFields(fn func(T) T) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.contextFields = fn(e.contextFields)
+}
+
+// KeyValue is a single error context field in a backend-agnostic representation.
+type KeyValue struct {
+	Key   string
+	Value any
+}
+
+// KeyValueError is implemented by the backend errors, providing the attached context
+// in a backend-agnostic representation.
+type KeyValueError interface {
+	error
+	KeyValues() []KeyValue
+}
+
+// ChainKeyValues returns the context fields of all backend errors within the error tree of err, see Collect.
+// Outer errors take precedence over inner errors for the same key.
+func ChainKeyValues(err error) []KeyValue {
+	var kvs []KeyValue
+	for _, e := range Collect[KeyValueError](err) {
+		for _, kv := range e.KeyValues() {
+			if !slices.ContainsFunc(kvs, func(x KeyValue) bool { return x.Key == kv.Key }) {
+				kvs = append(kvs, kv)
+			}
+		}
+	}
+	return kvs
+}
+
+// Format implements fmt.Formatter. The %s & %v verbs print the error message,
+// while %+v additionally prints the attached context and the panic stack trace, if any.
+// Formatting is delegated to cockroachdb/errors, so that the output is consistent
+// with the errors created by that package.
+func (e *BaseError[T]) Format(s fmt.State, verb rune) {
+	errbase.FormatError(e, s, verb)
+}
+
+// FormatError implements errbase.Formatter. The context fields are printed with the %+v verb.
+func (e *BaseError[T]) FormatError(p errbase.Printer) error {
+	if p.Detail() {
+		p.Printf("%+v", e.ContextFields())
+	}
+	return e.originalErr
+}
+
+// FormatKeyValues is intended for the errbase.Formatter implementation of backend errors,
+// printing the given context fields as key=value lines.
+// The panic stack trace field is omitted, since the stack trace is printed by the Panic cause.
+func (e *BaseError[T]) FormatKeyValues(p errbase.Printer, kvs []KeyValue) error {
+	if p.Detail() {
+		var n int
+		for _, kv := range kvs {
+			if e.IsPanic() && kv.Key == FieldNamePanicStackTrace {
+				continue
+			}
+			if n > 0 {
+				p.Print("\n")
+			}
+			p.Printf("%s=%v", kv.Key, kv.Value)
+			n++
+		}
+	}
+	return e.originalErr
+}
+
 func (e *BaseError[T]) MarkAsPanic() *BaseError[T] {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.isPanic = true
 	return e
 }
@@ -56,46 +142,156 @@ func (e *BaseError[T]) IsPanic() bool {
 	if e == nil {
 		return false
 	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	return e.isPanic
 }
 
 // Collect finds aggregates all errors that match the given target type,
-// within the error chain of err. The resulting slice contains target error instances
-// in reverse order.
+// within the error tree of err. The tree is traversed depth-first, including the branches
+// of errors that wrap multiple errors, e.g. those created by errors.Join.
+// The resulting slice contains target error instances in reverse order,
+// i.e. the outermost error first.
 func Collect[T error](err error) []T {
+	nodes := CollectTree[T](err)
+	if len(nodes) == 0 {
+		return nil
+	}
+	found := make([]T, 0, len(nodes))
+	for _, n := range nodes {
+		found = append(found, n.Err)
+	}
+	return found
+}
+
+// Node is an error found within an error tree.
+type Node[T error] struct {
+	Err T
+	// Path contains the index of the wrapped error at each level, from the root of the tree to Err.
+	// Errors wrapping a single error have only one child with index 0,
+	// while errors wrapping multiple errors, e.g. created by errors.Join, have one child per branch.
+	// The path of the root error is empty.
+	Path []int
+}
+
+// CollectTree finds all errors that match the given target type within the error tree of err,
+// along with the path to each one of them. The tree is traversed depth-first, in pre-order.
+func CollectTree[T error](err error) []Node[T] {
 	if err == nil {
 		return nil
 	}
-	var found []T
-	currentErr := err
-	for currentErr != nil {
-		var target T
-		if errors.As(currentErr, &target) {
-			var last T
-			if len(found) > 0 {
-				last = found[len(found)-1]
+	var found []Node[T]
+	var walk func(e error, path []int)
+	walk = func(e error, path []int) {
+		if target, ok := matchNode[T](e); ok {
+			found = append(found, Node[T]{Err: target, Path: slices.Clone(path)})
+		}
+		switch x := e.(type) {
+		case interface{ Unwrap() error }:
+			if next := x.Unwrap(); next != nil {
+				walk(next, append(path, 0))
 			}
-			if !errors.Is(target, last) {
-				found = append(found, target)
+		case interface{ Unwrap() []error }:
+			for i, next := range x.Unwrap() {
+				if next != nil {
+					walk(next, append(path, i))
+				}
 			}
 		}
-		currentErr = errors.Unwrap(currentErr)
 	}
+	walk(err, nil)
 	return found
 }
 
+// matchNode reports whether the error itself, disregarding the errors it wraps,
+// matches the target type, following the same rules as errors.As.
+func matchNode[T error](err error) (T, bool) {
+	if target, ok := err.(T); ok {
+		return target, true
+	}
+	var target T
+	if x, ok := err.(interface{ As(any) bool }); ok && x.As(&target) {
+		return target, true
+	}
+	return target, false
+}
+
 const FieldNamePanicStackTrace = "stack"
 const FieldNamePanicMessage = "panic"
+const FieldNamePanicElidedFrames = "stack_elided_frames"
+const FieldNameContextError = "context_error"
+const FieldNameContextCause = "context_cause"
 
+// Panic describes a recovered panic.
+// It implements the error interface, so that it can be used as the cause of the error
+// produced by an ErrorGenerator. If the recovered value is an error, it is exposed via Unwrap,
+// allowing errors.Is & errors.As to match it, e.g. a runtime.Error.
 type Panic struct {
 	Message string
-	Stack   []string
+	// Value is the original value passed to panic.
+	Value any
+	// Frames contains the goroutine stack trace at the point of recovery.
+	Frames []Frame
+	// ElidedFrames is the number of frames omitted from Frames due to Recoverer.MaxStackFrames.
+	ElidedFrames int
+	// ContextErr is the error of the context passed to Recoverer.WrapContext, if it was already
+	// done when the panic was recovered, e.g. context.DeadlineExceeded.
+	ContextErr error
+	// ContextCause is the cancellation cause of the context passed to Recoverer.WrapContext,
+	// if it differs from ContextErr.
+	ContextCause error
+}
+
+func (p Panic) Error() string {
+	return p.Message
+}
+
+// Unwrap returns the recovered value if it is an error, otherwise nil.
+func (p Panic) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// FormatError implements errbase.Formatter, so that the stack trace is printed with the %+v verb.
+func (p Panic) FormatError(pr errbase.Printer) error {
+	next := p.Unwrap()
+	// The message of an error value is printed by the next error in the chain.
+	prefix, found := strings.CutSuffix(p.Message, ": "+fmt.Sprint(next))
+	if next == nil || !found {
+		prefix = p.Message
+		next = nil
+	}
+	pr.Print(prefix)
+	if stack := p.Stack(); pr.Detail() && len(stack) > 0 {
+		pr.Print(strings.Join(stack, "\n"))
+	}
+	return next
+}
+
+// Format implements fmt.Formatter, see FormatError.
+func (p Panic) Format(s fmt.State, verb rune) {
+	errbase.FormatError(p, s, verb)
+}
+
+// Stack returns the string form of each stack frame.
+// If frames have been elided, a final "... N frames elided" marker line is included.
+func (p Panic) Stack() []string {
+	stack := make([]string, 0, len(p.Frames)+1)
+	for _, f := range p.Frames {
+		stack = append(stack, f.String())
+	}
+	if p.ElidedFrames > 0 {
+		stack = append(stack, fmt.Sprintf("... %d frames elided", p.ElidedFrames))
+	}
+	return stack
 }
 
 type ErrorGenerator[T error] func(p Panic) T
 
 func DefaultErrorGenerator(p Panic) error {
-	return fmt.Errorf("%s\n%s", p.Message, strings.Join(p.Stack, "\n"))
+	return fmt.Errorf("%w\n%s", p, strings.Join(p.Stack(), "\n"))
 }
 
 var _ ErrorGenerator[error] = DefaultErrorGenerator
@@ -107,10 +303,21 @@ type Recoverer[T error] struct {
 	// PanicValueTransform if set will try to format arbitrary panic value types,
 	// such as a struct or a map.
 	PanicValueTransform func(r any) (string, error)
-	// SkippedStackTraceLines sets the number of stack trace lines to be skipped.
-	// In-library stack trace lines may be considered irrelevant or noise and
-	// thus can be optionally skipped. By default, no lines are skipped.
-	SkippedStackTraceLines uint
+	// StackFilters are applied in order to each captured stack frame.
+	// Frames of this module (including the backends) are always filtered out, since they
+	// are only noise in the panic stack trace. NewRecoverer sets DropRuntimeFrames by default.
+	StackFilters []FrameFilter
+	// MaxStackFrames caps the number of retained stack frames, after filtering.
+	// Omitted frames are counted in Panic.ElidedFrames. By default, the depth is not limited.
+	MaxStackFrames uint
+	// OnPanic callbacks are invoked in order with every recovered panic and the error generated for it,
+	// regardless of which call site recovered the panic, e.g. in order to log, count or forward the error
+	// to an error tracker. Callbacks are invoked synchronously and should not panic.
+	OnPanic []func(Panic, T)
+	// Repanic if set decides whether a recovered panic is raised again with the original value,
+	// after the OnPanic callbacks have been invoked, so that the structured stack trace can be logged
+	// before the program exits. See RepanicOn, RepanicOnRuntimeError and RepanicAfter.
+	Repanic RepanicPolicy
 }
 
 func NewRecoverer[T error](newError ErrorGenerator[T]) Recoverer[T] {
@@ -119,24 +326,131 @@ func NewRecoverer[T error](newError ErrorGenerator[T]) Recoverer[T] {
 	}
 	return Recoverer[T]{
 		newErrorFunc: newError,
+		StackFilters: []FrameFilter{DropRuntimeFrames},
 	}
 }
 
 var ErrNewErrorFuncNotSet = errors.New("error generator function is not set")
 
+// ErrGoexit is reported when a wrapped function terminates by calling runtime.Goexit,
+// e.g. testing.T.FailNow. Since runtime.Goexit also terminates the calling goroutine,
+// the error is only delivered to functions that run during the goroutine exit,
+// e.g. the Go sink and the cancellation cause of WrapContext.
+var ErrGoexit = errors.New("runtime.Goexit was called")
+
 // Wrap allows recovery from panics for the given function.
 // Panics are translated and propagated as errors that can be handled accordingly.
+// panic(nil) is reported as a *runtime.PanicNilError value, even if the legacy
+// behavior is enabled with GODEBUG=panicnil=1.
 // Note: unrecovered panics can cause an abnormal program exit.
-func (r Recoverer[T]) Wrap(fn func() error) (err error) {
+func (r Recoverer[T]) Wrap(fn func() error) error {
 	if r.newErrorFunc == nil {
 		return fn()
 	}
+	return r.wrap(context.Background(), fn, nil)
+}
+
+// WrapContext allows recovery from panics for the given context-aware function, similarly to Wrap.
+// If the context is done at the time of recovery, its error and cancellation cause are recorded
+// in Panic.ContextErr & Panic.ContextCause respectively.
+func (r Recoverer[T]) WrapContext(ctx context.Context, fn func(context.Context) error) error {
+	if r.newErrorFunc == nil {
+		return fn(ctx)
+	}
+	return r.wrap(ctx, func() error {
+		return fn(ctx)
+	}, nil)
+}
+
+// WrapContextCancel is similar to WrapContext, additionally invoking cancel with the converted error
+// as the cause if fn panics, or with ErrGoexit if fn calls runtime.Goexit. Errors returned by fn
+// do not cancel. Sharing a derived context and its cancel function among sibling calls stops
+// all of them once any of them panics:
+//
+//	ctx, cancel := context.WithCancelCause(ctx)
+//	defer cancel(nil)
+//	for _, shard := range shards {
+//		recoverer.Go(func() error {
+//			return recoverer.WrapContextCancel(ctx, cancel, shard.Sync)
+//		}, sink)
+//	}
+func (r Recoverer[T]) WrapContextCancel(
+	ctx context.Context, cancel context.CancelCauseFunc, fn func(context.Context) error,
+) error {
+	if r.newErrorFunc == nil {
+		return fn(ctx)
+	}
+	returned := false
+	return r.wrap(ctx, func() error {
+		err := fn(ctx)
+		returned = true
+		return err
+	}, func(err error) {
+		if !returned && cancel != nil {
+			cancel(err)
+		}
+	})
+}
+
+// wrap calls fn, converting panics to errors. Abnormal termination is detected with flags,
+// instead of relying on the recovered value, which is nil for runtime.Goexit and for panic(nil)
+// with GODEBUG=panicnil=1. Since runtime.Goexit does not return to the caller, done, if non-nil,
+// is invoked with the resulting error in all cases, including ErrGoexit.
+func (r Recoverer[T]) wrap(ctx context.Context, fn func() error, done func(error)) (err error) {
+	normalReturn, recovered, panicked := false, false, false
 	defer func() {
-		if rv := recover(); rv != nil {
-			err = r.newErrorFunc(r.Format(rv))
+		if !normalReturn && !recovered && !panicked {
+			err = ErrGoexit
+		}
+		if done != nil {
+			done(err)
 		}
 	}()
-	err = fn()
+	func() {
+		defer func() {
+			if normalReturn {
+				return
+			}
+			if rv := recover(); rv != nil {
+				panicked = true
+				err = r.recovered(ctx, rv)
+			}
+		}()
+		err = fn()
+		normalReturn = true
+	}()
+	if !normalReturn {
+		recovered = true
+		if !panicked {
+			// panic(nil) with GODEBUG=panicnil=1.
+			err = r.recovered(ctx, new(runtime.PanicNilError))
+		}
+	}
+	return err
+}
+
+// recovered converts the recovered value to an error, see newError.
+func (r Recoverer[T]) recovered(ctx context.Context, rv any) error {
+	p := r.Format(rv)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		p.ContextErr = ctxErr
+		if cause := context.Cause(ctx); cause != ctxErr {
+			p.ContextCause = cause
+		}
+	}
+	return r.newError(p)
+}
+
+// newError converts the panic to an error and notifies the OnPanic callbacks.
+// The panic is raised again if required by the Repanic policy.
+func (r Recoverer[T]) newError(p Panic) T {
+	err := r.newErrorFunc(p)
+	for _, fn := range r.OnPanic {
+		fn(p, err)
+	}
+	if r.Repanic != nil && r.Repanic(p) {
+		panic(p.Value)
+	}
 	return err
 }
 
@@ -156,8 +470,37 @@ func (r Recoverer[T]) WrapFunc(fn func() error) func() error {
 	}
 }
 
+// Go starts fn in a new goroutine, ensuring that panics are converted to error values.
+// It is intended for fire-and-forget goroutines, e.g. background cache refreshers,
+// where no caller collects the return value.
+// A non-nil error, either returned by fn or converted from a panic, is delivered to sink.
+// If fn calls runtime.Goexit, ErrGoexit is delivered to sink.
+// If sink is nil, errors are discarded.
+//
+//	errs := make(chan error, 1)
+//	recoverer.Go(refreshCache, func(err error) {
+//		errs <- err
+//	})
+func (r Recoverer[T]) Go(fn func() error, sink func(error)) {
+	deliver := func(err error) {
+		if err != nil && sink != nil {
+			sink(err)
+		}
+	}
+	if r.newErrorFunc == nil {
+		go func() {
+			deliver(fn())
+		}()
+		return
+	}
+	go func() {
+		_ = r.wrap(context.Background(), fn, deliver)
+	}()
+}
+
 // Format transforms an arbitrary value thrown by panic to an error message
-// along with providing the current goroutine stack trace for the panic root cause.
+// along with providing the current goroutine stack frames for the panic root cause.
+// The stack frames are filtered according to StackFilters and MaxStackFrames.
 // If PanicValueTransform is non-nil, an attempt to format the recovered value is performed.
 // If the formatter function returns an error, a fallback approach is used and the failure
 // error message is appended to the standard message template.
@@ -180,19 +523,11 @@ func (r Recoverer[T]) Format(rv any) Panic {
 			baseMessage = fmt.Sprintf("%s: %v", FieldNamePanicMessage, v)
 		}
 	}
-	debugStack := debug.Stack()
-	stackLines := make([]string, 0, bytes.Count(debugStack, []byte{'\n'}))
-	scanner := bufio.NewScanner(bytes.NewReader(debugStack))
-	var lineNumber uint
-	for scanner.Scan() {
-		lineNumber++
-		if lineNumber <= r.SkippedStackTraceLines {
-			continue
-		}
-		stackLines = append(stackLines, scanner.Text())
-	}
+	frames, elided := filterFrames(callers(1), r.StackFilters, r.MaxStackFrames)
 	return Panic{
-		Message: baseMessage,
-		Stack:   stackLines,
+		Message:      baseMessage,
+		Value:        rv,
+		Frames:       frames,
+		ElidedFrames: elided,
 	}
 }
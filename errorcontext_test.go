@@ -1,11 +1,17 @@
 package errorcontext
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -49,6 +55,38 @@ func TestCollect(t *testing.T) {
 	assert.Nil(t, Collect[error](nil))
 }
 
+func TestCollect_Join(t *testing.T) {
+	t.Parallel()
+
+	newTestError := func(err error, attrs ...string) *testError {
+		return &testError{BaseError: NewBaseError(err, attrs)}
+	}
+	branch1 := newTestError(errors.New("branch 1"), "attr1")
+	branch2 := fmt.Errorf("branch 2: %w",
+		newTestError(newTestError(errors.New("branch 2"), "attr3"), "attr2"))
+	root := newTestError(errors.Join(branch1, nil, branch2), "attr0")
+
+	var attrs []string
+	for _, e := range Collect[*testError](root) {
+		attrs = append(attrs, e.ContextFields()...)
+	}
+	assert.Equal(t, []string{"attr0", "attr1", "attr2", "attr3"}, attrs)
+
+	nodes := CollectTree[*testError](root)
+	require.Len(t, nodes, 4)
+	assert.Equal(t, root, nodes[0].Err)
+	assert.Empty(t, nodes[0].Path)
+	assert.Equal(t, branch1, nodes[1].Err)
+	assert.Equal(t, []int{0, 0}, nodes[1].Path)
+	assert.Equal(t, []string{"attr2"}, nodes[2].Err.ContextFields())
+	assert.Equal(t, []int{0, 1, 0}, nodes[2].Path)
+	assert.Equal(t, []string{"attr3"}, nodes[3].Err.ContextFields())
+	assert.Equal(t, []int{0, 1, 0, 0}, nodes[3].Path)
+
+	assert.Nil(t, CollectTree[*testError](nil))
+	assert.Nil(t, Collect[*testError](errors.New("plain error")))
+}
+
 func TestRecoverer_Wrap(t *testing.T) {
 	t.Parallel()
 
@@ -63,7 +101,7 @@ func TestRecoverer_Wrap(t *testing.T) {
 		wantStackTrace string
 	}
 	newErrorFunc := func(p Panic) error {
-		return errors.New(p.Message + "\n" + strings.Join(p.Stack, "\n"))
+		return errors.New(p.Message + "\n" + strings.Join(p.Stack(), "\n"))
 	}
 	tests := []testCase[error]{
 		{
@@ -85,7 +123,7 @@ func TestRecoverer_Wrap(t *testing.T) {
 				return r
 			}(),
 			wantErrMessage: "panic: runtime error: invalid memory address or nil pointer",
-			wantStackTrace: "errorcontext/errorcontext_test.go",
+			wantStackTrace: "testing/testing.go",
 		},
 		{
 			name: "should format custom panic values",
@@ -111,7 +149,7 @@ func TestRecoverer_Wrap(t *testing.T) {
 				return r
 			}(),
 			wantErrMessage: `panic: {"field1":"undefined behavior","field2":"panic with map"}`,
-			wantStackTrace: "errorcontext/errorcontext_test.go",
+			wantStackTrace: "testing/testing.go",
 		},
 	}
 	for _, tt := range tests {
@@ -188,11 +226,131 @@ func TestRecoverer_Format(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			f := tt.r.Format(tt.args.rv)
 			assert.Equal(t, tt.want.Message, f.Message)
-			assert.NotEmpty(t, f.Stack)
+			require.NotEmpty(t, f.Frames)
+			assert.Equal(t, "testing.tRunner", f.Frames[0].Function)
 		})
 	}
 }
 
+func TestRecoverer_Wrap_StackFilters(t *testing.T) {
+	t.Parallel()
+
+	var p Panic
+	r := NewRecoverer(func(rp Panic) error {
+		p = rp
+		return errors.New(rp.Message)
+	})
+	r.MaxStackFrames = 1
+
+	err := r.Wrap(func() error {
+		panic("something bad happened")
+	})
+	require.Error(t, err)
+
+	// Frames of the module, including this test, are dropped.
+	require.Len(t, p.Frames, 1)
+	assert.Equal(t, "testing.tRunner", p.Frames[0].Function)
+	assert.Positive(t, p.ElidedFrames)
+
+	stack := p.Stack()
+	assert.Len(t, stack, 2)
+	assert.Equal(t, fmt.Sprintf("... %d frames elided", p.ElidedFrames), stack[1])
+}
+
+func TestRecoverer_Wrap_PanicValue(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecoverer(DefaultErrorGenerator)
+
+	t.Run("error value", func(t *testing.T) {
+		t.Parallel()
+
+		err := r.Wrap(func() error {
+			panic(fmt.Errorf("reading payload: %w", io.ErrUnexpectedEOF))
+		})
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+		var p Panic
+		require.ErrorAs(t, err, &p)
+		assert.Equal(t, "panic: reading payload: unexpected EOF", p.Message)
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		t.Parallel()
+
+		err := r.Wrap(func() error {
+			var m map[string]int
+			m["key"] = 1
+			return nil
+		})
+		var re runtime.Error
+		require.ErrorAs(t, err, &re)
+		assert.Contains(t, re.Error(), "assignment to entry in nil map")
+	})
+
+	t.Run("non-error value", func(t *testing.T) {
+		t.Parallel()
+
+		err := r.Wrap(func() error {
+			panic(42)
+		})
+		var p Panic
+		require.ErrorAs(t, err, &p)
+		assert.Equal(t, 42, p.Value)
+		assert.NoError(t, p.Unwrap())
+	})
+}
+
+func TestRecoverer_WrapContext(t *testing.T) {
+	t.Parallel()
+
+	var p Panic
+	newErrorFunc := func(rp Panic) error {
+		p = rp
+		return DefaultErrorGenerator(rp)
+	}
+
+	t.Run("passes the context through by default", func(t *testing.T) {
+		r := NewRecoverer(newErrorFunc)
+
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+		err := r.WrapContext(ctx, func(c context.Context) error {
+			assert.Equal(t, ctx, c)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("records the context error", func(t *testing.T) {
+		r := NewRecoverer(newErrorFunc)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+		err := r.WrapContext(ctx, func(ctx context.Context) error {
+			panic("something bad happened")
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, p.ContextErr, context.DeadlineExceeded)
+		assert.NoError(t, p.ContextCause)
+	})
+
+	t.Run("records the cancellation cause", func(t *testing.T) {
+		r := NewRecoverer(newErrorFunc)
+
+		errShutdown := errors.New("shutting down")
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(errShutdown)
+		err := r.WrapContext(ctx, func(ctx context.Context) error {
+			panic("something bad happened")
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, p.ContextErr, context.Canceled)
+		assert.ErrorIs(t, p.ContextCause, errShutdown)
+	})
+}
+
 func TestRecoverer_WrapFunc(t *testing.T) {
 	t.Parallel()
 
@@ -231,6 +389,242 @@ func TestRecoverer_WrapFunc(t *testing.T) {
 	}
 }
 
+func TestRecoverer_Go(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecoverer(DefaultErrorGenerator)
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name    string
+		fn      func() error
+		wantErr func(t *testing.T, err error)
+	}{
+		{
+			name: "delivers recovered panics",
+			fn: func() error {
+				panic("something bad happened")
+			},
+			wantErr: func(t *testing.T, err error) {
+				var p Panic
+				require.ErrorAs(t, err, &p)
+				assert.Equal(t, "panic: something bad happened", p.Message)
+			},
+		},
+		{
+			name: "delivers returned errors",
+			fn: func() error {
+				return errFailed
+			},
+			wantErr: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, errFailed)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			errs := make(chan error, 1)
+			r.Go(tt.fn, func(err error) {
+				errs <- err
+			})
+			tt.wantErr(t, <-errs)
+		})
+	}
+
+	t.Run("nil sink discards errors", func(t *testing.T) {
+		t.Parallel()
+
+		done := make(chan struct{})
+		r.Go(func() error {
+			defer close(done)
+			panic("something bad happened")
+		}, nil)
+		<-done
+	})
+}
+
+func TestRecoverer_OnPanic(t *testing.T) {
+	t.Parallel()
+
+	type observed struct {
+		message string
+		err     error
+	}
+	newRecoverer := func() (Recoverer[error], chan observed) {
+		ch := make(chan observed, 4)
+		r := NewRecoverer(DefaultErrorGenerator)
+		r.OnPanic = []func(Panic, error){
+			func(p Panic, err error) {
+				ch <- observed{message: p.Message, err: err}
+			},
+			func(p Panic, err error) {
+				ch <- observed{message: "second: " + p.Message, err: err}
+			},
+		}
+		return r, ch
+	}
+
+	tests := []struct {
+		name string
+		run  func(r Recoverer[error], fn func() error) error
+	}{
+		{
+			name: "Wrap",
+			run: func(r Recoverer[error], fn func() error) error {
+				return r.Wrap(fn)
+			},
+		},
+		{
+			name: "WrapContext",
+			run: func(r Recoverer[error], fn func() error) error {
+				return r.WrapContext(context.Background(), func(context.Context) error {
+					return fn()
+				})
+			},
+		},
+		{
+			name: "Go",
+			run: func(r Recoverer[error], fn func() error) error {
+				errs := make(chan error, 1)
+				r.Go(fn, func(err error) {
+					errs <- err
+				})
+				// Both the panic and the failure are delivered to the sink.
+				select {
+				case err := <-errs:
+					return err
+				case <-time.After(10 * time.Second):
+					return errors.New("timed out waiting for the sink")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, ch := newRecoverer()
+			err := tt.run(r, func() error {
+				panic("something bad happened")
+			})
+			require.Error(t, err)
+			assert.Equal(t, observed{message: "panic: something bad happened", err: err}, <-ch)
+			assert.Equal(t, observed{message: "second: panic: something bad happened", err: err}, <-ch)
+
+			err = tt.run(r, func() error {
+				return errors.New("failed")
+			})
+			assert.EqualError(t, err, "failed")
+			assert.Empty(t, ch)
+		})
+	}
+}
+
+func TestRecoverer_Wrap_PanicNil(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecoverer(DefaultErrorGenerator)
+	err := r.Wrap(func() error {
+		panic(nil)
+	})
+
+	var perr *runtime.PanicNilError
+	require.ErrorAs(t, err, &perr)
+	var p Panic
+	require.ErrorAs(t, err, &p)
+	assert.Contains(t, p.Message, "panic called with nil argument")
+}
+
+func TestRecoverer_WrapContextCancel(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecoverer(DefaultErrorGenerator)
+
+	t.Run("stops sibling work on panic", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		defer cancel(nil)
+
+		sibling := make(chan error, 1)
+		started := make(chan struct{})
+		go func() {
+			sibling <- r.WrapContextCancel(ctx, cancel, func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				return context.Cause(ctx)
+			})
+		}()
+		<-started
+
+		err := r.WrapContextCancel(ctx, cancel, func(context.Context) error {
+			panic("something bad happened")
+		})
+		require.Error(t, err)
+
+		select {
+		case cause := <-sibling:
+			assert.Equal(t, err, cause)
+		case <-time.After(10 * time.Second):
+			t.Fatal("sibling was not canceled")
+		}
+	})
+
+	t.Run("does not cancel on return", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		defer cancel(nil)
+
+		failed := errors.New("failed")
+		err := r.WrapContextCancel(ctx, cancel, func(context.Context) error {
+			return failed
+		})
+		require.ErrorIs(t, err, failed)
+		assert.NoError(t, ctx.Err())
+	})
+}
+
+func TestRecoverer_Goexit(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecoverer(DefaultErrorGenerator)
+	var observed []Panic
+	r.OnPanic = []func(Panic, error){
+		func(p Panic, _ error) {
+			observed = append(observed, p)
+		},
+	}
+
+	t.Run("Go delivers ErrGoexit", func(t *testing.T) {
+		errs := make(chan error, 1)
+		r.Go(func() error {
+			runtime.Goexit()
+			return nil
+		}, func(err error) {
+			errs <- err
+		})
+		assert.ErrorIs(t, <-errs, ErrGoexit)
+	})
+
+	t.Run("WrapContextCancel cancels with ErrGoexit", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		defer cancel(nil)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = r.WrapContextCancel(ctx, cancel, func(context.Context) error {
+				runtime.Goexit()
+				return nil
+			})
+			t.Error("WrapContextCancel must not return")
+		}()
+		<-done
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+		assert.ErrorIs(t, context.Cause(ctx), ErrGoexit)
+	})
+
+	assert.Empty(t, observed)
+}
+
 func TestDefaultErrorGenerator(t *testing.T) {
 	t.Parallel()
 
@@ -248,11 +642,14 @@ func TestDefaultErrorGenerator(t *testing.T) {
 			args: args{
 				p: Panic{
 					Message: "panic: something bad happened",
-					Stack:   []string{"line1", "line2"},
+					Frames: []Frame{
+						{Function: "main.run", File: "/app/main.go", Line: 12},
+						{Function: "main.main", File: "/app/main.go", Line: 5},
+					},
 				},
 			},
 			wantErr:        assert.Error,
-			wantErrMessage: "panic: something bad happened\nline1\nline2",
+			wantErrMessage: "panic: something bad happened\nmain.run\n\t/app/main.go:12\nmain.main\n\t/app/main.go:5",
 		},
 	}
 	for _, tt := range tests {
@@ -285,6 +682,32 @@ func TestBaseError_MarkAsPanic(t *testing.T) {
 	})
 }
 
+func TestBaseError_UpdateContextFields(t *testing.T) {
+	t.Parallel()
+
+	err := NewBaseError[[]string](errors.New("test error"), nil)
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			err.UpdateContextFields(func(fields []string) []string {
+				return append(slices.Clip(fields), fmt.Sprintf("field%d", i))
+			})
+		})
+		wg.Go(func() {
+			_ = fmt.Sprintf("%+v", err)
+			_ = err.IsPanic()
+		})
+	}
+	wg.Go(func() {
+		err.MarkAsPanic()
+	})
+	wg.Wait()
+
+	assert.Len(t, err.ContextFields(), 50)
+	assert.True(t, err.IsPanic())
+}
+
 func TestNewRecoverer(t *testing.T) {
 	t.Parallel()
 
@@ -292,3 +715,136 @@ func TestNewRecoverer(t *testing.T) {
 		NewRecoverer[error](nil)
 	})
 }
+
+func TestBaseError_Format(t *testing.T) {
+	t.Parallel()
+
+	err := &testError{
+		BaseError: NewBaseError(errors.New("original error"), []string{"attr1", "attr2"}),
+	}
+	assert.Equal(t, "original error", fmt.Sprintf("%v", err))
+	assert.Equal(t, "original error", fmt.Sprintf("%s", err))
+	assert.Equal(t, `"original error"`, fmt.Sprintf("%q", err))
+	assert.Equal(t, `original error
+(1) [attr1 attr2]
+Wraps: (2) original error
+Error types: (1) *errorcontext.BaseError[[]string] (2) *errors.errorString`, fmt.Sprintf("%+v", err.BaseError))
+}
+
+func TestBaseError_FormatKeyValues(t *testing.T) {
+	t.Parallel()
+
+	err := &BaseError[[]KeyValue]{
+		originalErr: Panic{
+			Message: "panic: something bad happened",
+			Value:   "something bad happened",
+			Frames:  []Frame{{Function: "main.main", File: "/app/main.go", Line: 5}},
+		},
+		contextFields: []KeyValue{
+			{Key: "path", Value: "/a/b"},
+			{Key: FieldNamePanicStackTrace, Value: []string{"omitted"}},
+			{Key: "attempt", Value: 3},
+		},
+	}
+	err.MarkAsPanic()
+
+	p := &testPrinter{detail: true}
+	next := err.FormatKeyValues(p, err.ContextFields())
+	assert.Equal(t, err.originalErr, next)
+	assert.Equal(t, "path=/a/b\nattempt=3", p.String())
+
+	p = &testPrinter{}
+	_ = err.FormatKeyValues(p, err.ContextFields())
+	assert.Empty(t, p.String())
+}
+
+type testPrinter struct {
+	strings.Builder
+	detail bool
+}
+
+func (p *testPrinter) Print(args ...any) {
+	_, _ = fmt.Fprint(p, args...)
+}
+
+func (p *testPrinter) Printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(p, format, args...)
+}
+
+func (p *testPrinter) Detail() bool {
+	return p.detail
+}
+
+func TestPanic_Format(t *testing.T) {
+	t.Parallel()
+
+	frames := []Frame{{Function: "main.main", File: "/app/main.go", Line: 5}}
+	tests := []struct {
+		name  string
+		p     Panic
+		want  string
+		wantV string
+	}{
+		{
+			name: "error value",
+			p: Panic{
+				Message: "panic: unexpected EOF",
+				Value:   io.ErrUnexpectedEOF,
+				Frames:  frames,
+			},
+			wantV: "panic: unexpected EOF",
+			want: `panic: unexpected EOF
+(1) panic
+  | main.main
+  | 	/app/main.go:5
+Wraps: (2) unexpected EOF
+Error types: (1) errorcontext.Panic (2) *errors.errorString`,
+		},
+		{
+			name: "arbitrary value",
+			p: Panic{
+				Message:      "panic: 42",
+				Value:        42,
+				Frames:       frames,
+				ElidedFrames: 3,
+			},
+			wantV: "panic: 42",
+			want: `panic: 42
+(1) panic: 42
+  | main.main
+  | 	/app/main.go:5
+  | ... 3 frames elided
+Error types: (1) errorcontext.Panic`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantV, fmt.Sprintf("%v", tt.p))
+			assert.Equal(t, tt.want, fmt.Sprintf("%+v", tt.p))
+		})
+	}
+}
+
+func TestChainKeyValues(t *testing.T) {
+	t.Parallel()
+
+	inner := &kvError{
+		BaseError: NewBaseError(errors.New("connection reset"), []KeyValue{
+			{Key: "host", Value: "db-1"},
+			{Key: "attempt", Value: 1},
+		}),
+	}
+	outer := &kvError{
+		BaseError: NewBaseError(fmt.Errorf("query failed: %w", inner), []KeyValue{
+			{Key: "attempt", Value: 3},
+		}),
+	}
+
+	assert.Equal(t, []KeyValue{
+		{Key: "attempt", Value: 3},
+		{Key: "host", Value: "db-1"},
+	}, ChainKeyValues(fmt.Errorf("wrapped: %w", outer)))
+	assert.Nil(t, ChainKeyValues(errors.New("plain error")))
+}
@@ -0,0 +1,150 @@
+// Package group provides a drop-in replacement for golang.org/x/sync/errgroup,
+// where every task runs through an errorcontext.Recoverer,
+// so that a panic in a single task cannot crash the process.
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+type token struct{}
+
+// Group is a collection of goroutines working on subtasks that are part of the same overall task.
+// Panics in tasks are recovered and converted to errors using the configured Recoverer.
+//
+// A zero Group is valid, has no limit on the number of active goroutines,
+// does not cancel on error and converts panics with errorcontext.DefaultErrorGenerator.
+type Group struct {
+	// JoinErrors if set makes Wait return all task errors joined with errors.Join,
+	// instead of only the first one.
+	JoinErrors bool
+
+	wrap   func(fn func() error) error
+	cancel func(error)
+
+	wg  sync.WaitGroup
+	sem chan token
+
+	errOnce sync.Once
+	err     error
+	mu      sync.Mutex
+	errs    []error
+}
+
+var defaultRecoverer = errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator)
+
+// New returns a Group, which runs tasks through the given Recoverer.
+func New[T error](r errorcontext.Recoverer[T]) *Group {
+	return &Group{wrap: r.Wrap}
+}
+
+// WithContext returns a new Group and an associated Context derived from ctx.
+// The derived Context is canceled the first time a function passed to Go
+// returns a non-nil error or panics, or the first time Wait returns, whichever occurs first.
+func WithContext[T error](ctx context.Context, r errorcontext.Recoverer[T]) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{wrap: r.Wrap, cancel: cancel}, ctx
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// Wait blocks until all function calls from the Go method have returned,
+// then returns the first non-nil error (if any) from them.
+// If JoinErrors is set, all non-nil errors are returned joined instead.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	if g.JoinErrors {
+		return errors.Join(g.errs...)
+	}
+	return g.err
+}
+
+// Go calls the given function in a new goroutine.
+// Panics are recovered and converted to errors.
+//
+// The first call to Go must happen before a Wait.
+// It blocks until the new goroutine can be added without the number of
+// goroutines in the group exceeding the configured limit.
+//
+// The first goroutine in the group that returns a non-nil error or panics will cancel the associated Context, if any.
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- token{}
+	}
+	g.start(f)
+}
+
+// TryGo calls the given function in a new goroutine only if the number of
+// active goroutines in the group is currently below the configured limit.
+//
+// The return value reports whether the goroutine was started.
+func (g *Group) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- token{}:
+			// Note: this allows barging iff channels in general allow barging.
+		default:
+			return false
+		}
+	}
+	g.start(f)
+	return true
+}
+
+func (g *Group) start(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+
+		if err := g.run(f); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(g.err)
+				}
+			})
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+		}
+	}()
+}
+
+func (g *Group) run(f func() error) error {
+	if g.wrap == nil {
+		return defaultRecoverer.Wrap(f)
+	}
+	return g.wrap(f)
+}
+
+// SetLimit limits the number of active goroutines in this group to at most n.
+// A negative value indicates no limit.
+// A limit of zero will prevent any new goroutines from being added.
+//
+// Any subsequent call to the Go method will block until it can add an active
+// goroutine without exceeding the configured limit.
+//
+// The limit must not be modified while any goroutines in the group are active.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic(fmt.Errorf("group: modify limit while %v goroutines in the group are still active", len(g.sem)))
+	}
+	g.sem = make(chan token, n)
+}
@@ -0,0 +1,154 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/georgepsarakis/errorcontext"
+	zaperrorcontext "github.com/georgepsarakis/errorcontext/backend/zap"
+)
+
+var errTask = errors.New("task failed")
+
+func TestGroup_ZeroValue(t *testing.T) {
+	t.Parallel()
+
+	var g Group
+	g.Go(func() error {
+		panic("something bad happened")
+	})
+	g.Go(func() error {
+		return nil
+	})
+
+	var err error
+	require.NotPanics(t, func() {
+		err = g.Wait()
+	})
+	var p errorcontext.Panic
+	require.ErrorAs(t, err, &p)
+	assert.Equal(t, "panic: something bad happened", p.Message)
+}
+
+func TestGroup_Go(t *testing.T) {
+	t.Parallel()
+
+	g := New(errorcontext.NewRecoverer(zaperrorcontext.FromPanic))
+	g.Go(func() error {
+		var m map[string]int
+		m["key"]++
+		return nil
+	})
+
+	err := g.Wait()
+	var ze *zaperrorcontext.Error
+	require.ErrorAs(t, err, &ze)
+	assert.True(t, ze.IsPanic())
+}
+
+func TestGroup_JoinErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		joinErrors bool
+		wantErrs   int
+	}{
+		{name: "returns the first error", joinErrors: false, wantErrs: 1},
+		{name: "returns all errors joined", joinErrors: true, wantErrs: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := New(errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator))
+			g.JoinErrors = tt.joinErrors
+			g.Go(func() error {
+				panic("something bad happened")
+			})
+			g.Go(func() error {
+				return errTask
+			})
+			g.Go(func() error {
+				return nil
+			})
+
+			err := g.Wait()
+			require.Error(t, err)
+			if tt.joinErrors {
+				assert.ErrorIs(t, err, errTask)
+				var p errorcontext.Panic
+				assert.ErrorAs(t, err, &p)
+				joined, ok := err.(interface{ Unwrap() []error })
+				require.True(t, ok)
+				assert.Len(t, joined.Unwrap(), tt.wantErrs)
+			}
+		})
+	}
+
+	t.Run("returns nil without errors", func(t *testing.T) {
+		t.Parallel()
+
+		g := Group{JoinErrors: true}
+		g.Go(func() error {
+			return nil
+		})
+		assert.NoError(t, g.Wait())
+	})
+}
+
+func TestWithContext(t *testing.T) {
+	t.Parallel()
+
+	g, ctx := WithContext(context.Background(), errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator))
+	g.Go(func() error {
+		panic("something bad happened")
+	})
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := g.Wait()
+	var p errorcontext.Panic
+	require.ErrorAs(t, err, &p)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.Equal(t, err, context.Cause(ctx))
+}
+
+func TestGroup_SetLimit(t *testing.T) {
+	t.Parallel()
+
+	var g Group
+	g.SetLimit(1)
+
+	release := make(chan struct{})
+	var active atomic.Int32
+	require.True(t, g.TryGo(func() error {
+		active.Add(1)
+		<-release
+		return nil
+	}))
+	assert.False(t, g.TryGo(func() error {
+		active.Add(1)
+		return nil
+	}))
+	assert.Panics(t, func() {
+		g.SetLimit(2)
+	})
+	close(release)
+
+	require.NoError(t, g.Wait())
+	assert.Equal(t, int32(1), active.Load())
+
+	g.SetLimit(-1)
+	assert.True(t, g.TryGo(func() error {
+		return nil
+	}))
+	require.NoError(t, g.Wait())
+}
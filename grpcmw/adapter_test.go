@@ -0,0 +1,57 @@
+package grpcmw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	slogerrorcontext "github.com/georgepsarakis/errorcontext/backend/slog"
+	zerologerrorcontext "github.com/georgepsarakis/errorcontext/backend/zerolog"
+)
+
+func TestAdapters(t *testing.T) {
+	t.Parallel()
+
+	info := CallInfo{FullMethod: "/grpc.health.v1.Health/Check", Peer: "192.0.2.1:1234"}
+	cause := errors.New("something bad happened")
+
+	t.Run("zerolog", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		adapter := NewZerologAdapter(zerolog.New(&output))
+		err := adapter.WithFields(cause, info)
+		var zerr *zerologerrorcontext.Error
+		require.ErrorAs(t, err, &zerr)
+		assert.Equal(t,
+			map[string]any{FieldNameMethod: info.FullMethod, FieldNamePeer: info.Peer},
+			zerr.ContextFields())
+		adapter.Log(context.Background(), err)
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+		assert.Equal(t, LogMessage, record["message"])
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		adapter := NewSlogAdapter(slog.New(slog.NewJSONHandler(&output, nil)))
+		err := adapter.WithFields(cause, CallInfo{FullMethod: info.FullMethod})
+		assert.Equal(t,
+			[]slog.Attr{slog.String(FieldNameMethod, info.FullMethod)},
+			slogerrorcontext.AsContext(err))
+		adapter.Log(context.Background(), err)
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+		assert.Equal(t, LogMessage, record["msg"])
+		assert.Equal(t, map[string]any{FieldNameMethod: info.FullMethod}, record["error_context"])
+	})
+}
@@ -0,0 +1,150 @@
+// Package grpcmw provides gRPC server interceptors, which recover panics in handlers
+// through an errorcontext.Recoverer, log them in structured form along with the call information
+// and respond with a codes.Internal status.
+package grpcmw
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+const (
+	FieldNameMethod = "grpc_method"
+	FieldNamePeer   = "peer"
+)
+
+// CallInfo describes the call during which a panic was recovered.
+type CallInfo struct {
+	// FullMethod is the full RPC method name, e.g. /package.Service/Method.
+	FullMethod string
+	// Peer is the address of the client, if known.
+	Peer string
+}
+
+// Adapter attaches the call information to errors and logs them,
+// see NewZapAdapter, NewZerologAdapter and NewSlogAdapter.
+// The adapter backend should match the error generator of the Recoverer, e.g. zaperrorcontext.FromPanic
+// for NewZapAdapter, so that the panic context is rendered along with the call information.
+type Adapter interface {
+	// WithFields returns err with the call information attached as error context.
+	WithFields(err error, info CallInfo) error
+	// Log logs the error of a recovered panic.
+	Log(ctx context.Context, err error)
+}
+
+// LogMessage is the message of log records for recovered panics.
+const LogMessage = "recovered panic in gRPC handler"
+
+// Interceptor recovers panics in gRPC handlers.
+type Interceptor struct {
+	// ExposePanicMessage if set sends the panic message, e.g. "panic: something bad happened",
+	// as the status message to clients. By default, the panic details are only logged
+	// and the status message is the generic codes.Internal description.
+	ExposePanicMessage bool
+
+	wrap    func(ctx context.Context, fn func(context.Context) error) error
+	adapter Adapter
+}
+
+// New returns an Interceptor, which runs handlers through the given Recoverer
+// and logs recovered panics with adapter. If adapter is nil, recovered panics are only
+// converted to status errors, e.g. when logged by Recoverer.OnPanic callbacks.
+//
+//	i := grpcmw.New(recoverer, grpcmw.NewSlogAdapter(logger))
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(i.UnaryServerInterceptor()),
+//		grpc.ChainStreamInterceptor(i.StreamServerInterceptor()))
+func New[T error](r errorcontext.Recoverer[T], adapter Adapter) *Interceptor {
+	return &Interceptor{
+		wrap:    r.WrapContext,
+		adapter: adapter,
+	}
+}
+
+// UnaryServerInterceptor returns a unary server interceptor, which converts panics to errors
+// with the call information attached, see Interceptor.
+func (i *Interceptor) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		err = i.recover(ctx, info.FullMethod, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a stream server interceptor, which converts panics to errors
+// with the call information attached, see Interceptor.
+func (i *Interceptor) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return i.recover(ss.Context(), info.FullMethod, func(context.Context) error {
+			return handler(srv, ss)
+		})
+	}
+}
+
+// recover calls fn, returning errors returned by fn as-is. Panics are converted to errors,
+// which are logged and returned with the codes.Internal status, see StatusError.
+func (i *Interceptor) recover(ctx context.Context, fullMethod string, fn func(context.Context) error) error {
+	returned := false
+	err := i.wrap(ctx, func(ctx context.Context) error {
+		err := fn(ctx)
+		returned = true
+		return err
+	})
+	if returned || err == nil {
+		return err
+	}
+	info := CallInfo{FullMethod: fullMethod}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.Peer = p.Addr.String()
+	}
+	if i.adapter != nil {
+		err = i.adapter.WithFields(err, info)
+		i.adapter.Log(ctx, err)
+	}
+	return &StatusError{
+		err:    err,
+		status: status.New(codes.Internal, statusMessage(err, i.ExposePanicMessage)),
+	}
+}
+
+// statusMessage returns the generic codes.Internal description, unless exposePanicMessage is set,
+// in which case the panic message is returned. The error message is never used, since it may include
+// the stack trace, e.g. for errorcontext.DefaultErrorGenerator.
+func statusMessage(err error, exposePanicMessage bool) string {
+	var p errorcontext.Panic
+	if exposePanicMessage && errors.As(err, &p) {
+		return p.Message
+	}
+	return codes.Internal.String()
+}
+
+// StatusError is the error returned by the interceptors for recovered panics.
+// It carries the codes.Internal status sent to the client, while the error context
+// remains accessible to outer interceptors through Unwrap.
+type StatusError struct {
+	err    error
+	status *status.Status
+}
+
+func (e *StatusError) Error() string {
+	return e.status.Err().Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus returns the status sent to the client, see status.FromError.
+func (e *StatusError) GRPCStatus() *status.Status {
+	return e.status
+}
@@ -0,0 +1,160 @@
+package grpcmw
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/georgepsarakis/errorcontext"
+	zaperrorcontext "github.com/georgepsarakis/errorcontext/backend/zap"
+	"github.com/georgepsarakis/errorcontext/internal/logadapter/logadaptertest"
+)
+
+var errUnknownService = errors.New("unknown service")
+
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (healthServer) Check(_ context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	switch req.GetService() {
+	case "panic":
+		panic("something bad happened")
+	case "error":
+		return nil, status.Error(codes.NotFound, "unknown service")
+	case "context":
+		return nil, zaperrorcontext.NewError(errUnknownService, zap.String("service", req.GetService()))
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (healthServer) Watch(*grpc_health_v1.HealthCheckRequest, grpc.ServerStreamingServer[grpc_health_v1.HealthCheckResponse]) error {
+	panic("something bad happened")
+}
+
+func newClient(t *testing.T, i *Interceptor, opts ...grpc.ServerOption) grpc_health_v1.HealthClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	// Interceptors of opts are outer to the recovery interceptors.
+	server := grpc.NewServer(append(opts,
+		grpc.ChainUnaryInterceptor(i.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(i.StreamServerInterceptor()),
+	)...)
+	grpc_health_v1.RegisterHealthServer(server, healthServer{})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestInterceptor_UnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	adapter := &logadaptertest.Recorder[CallInfo]{}
+	client := newClient(t, New(errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator), adapter))
+	ctx := context.Background()
+
+	resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "error"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "panic"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "Internal", status.Convert(err).Message())
+
+	require.Len(t, adapter.Errs(), 1)
+	var p errorcontext.Panic
+	require.ErrorAs(t, adapter.Errs()[0], &p)
+	assert.Equal(t, []CallInfo{{
+		FullMethod: grpc_health_v1.Health_Check_FullMethodName,
+		Peer:       "bufconn",
+	}}, adapter.Info())
+}
+
+func TestInterceptor_ExposePanicMessage(t *testing.T) {
+	t.Parallel()
+
+	i := New(errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator), nil)
+	i.ExposePanicMessage = true
+	client := newClient(t, i)
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "panic"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "panic: something bad happened", status.Convert(err).Message())
+}
+
+func TestInterceptor_StreamServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	core, observedLogs := observer.New(zap.InfoLevel)
+	i := New(errorcontext.NewRecoverer(zaperrorcontext.FromPanic), NewZapAdapter(zap.New(core)))
+	client := newClient(t, i)
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	logs := observedLogs.All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, LogMessage, logs[0].Message)
+	obj, ok := logs[0].ContextMap()["error"].(map[string]any)
+	require.True(t, ok)
+	context, ok := obj["context"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, grpc_health_v1.Health_Watch_FullMethodName, context[FieldNameMethod])
+	assert.Equal(t, "bufconn", context[FieldNamePeer])
+	assert.Equal(t, "panic: something bad happened", context[errorcontext.FieldNamePanicMessage])
+	assert.NotEmpty(t, context[errorcontext.FieldNamePanicStackTrace])
+}
+
+func TestStatusError(t *testing.T) {
+	t.Parallel()
+
+	var outer error
+	i := New(errorcontext.NewRecoverer(zaperrorcontext.FromPanic), nil)
+	client := newClient(t, i, grpc.ChainUnaryInterceptor(
+		func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			resp, err := handler(ctx, req)
+			outer = err
+			return resp, err
+		}))
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "panic"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	var serr *StatusError
+	require.ErrorAs(t, outer, &serr)
+	assert.Equal(t, codes.Internal, status.Code(outer))
+	assert.EqualError(t, outer, "rpc error: code = Internal desc = Internal")
+	var zerr *zaperrorcontext.Error
+	require.True(t, errors.As(outer, &zerr))
+	assert.True(t, zerr.IsPanic())
+}
@@ -0,0 +1,190 @@
+package grpcmw
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+// CodeMapping maps errors matching Err, see errors.Is, to Code.
+type CodeMapping struct {
+	Err  error
+	Code codes.Code
+}
+
+// Converter converts error chains containing errorcontext errors to gRPC statuses,
+// carrying the context fields in an errdetails.ErrorInfo detail, see FromStatus for the reverse conversion.
+type Converter struct {
+	// Codes are evaluated in order, the first mapping with a matching error determines the status code.
+	Codes []CodeMapping
+	// Domain is the logical grouping of the ErrorInfo reason, e.g. the service name.
+	Domain string
+	// Reason is the ErrorInfo reason. By default, it is the upper snake case name of the status code,
+	// e.g. NOT_FOUND.
+	Reason string
+	// ExposePanicMessage if set uses the panic message of recovered panics as the status message
+	// and retains it in the ErrorInfo metadata. By default, the panic details are not sent to clients.
+	ExposePanicMessage bool
+}
+
+// Status converts err to a status. The status code is determined by, in order of precedence:
+//   - the first matching Codes mapping.
+//   - the Code method of an error in the chain, e.g. Code() codes.Code.
+//   - the status of an error in the chain, see status.FromError.
+//   - codes.Internal for recovered panics.
+//   - codes.Unknown.
+//
+// The status message is the error message, except for recovered panics, where the status code description,
+// e.g. Internal, is used, since the error message may include the panic details and the stack trace,
+// and for errors carrying a status, where the status message is used.
+// The context fields of the error chain, see errorcontext.ChainKeyValues, are attached as the ErrorInfo metadata,
+// with all values JSON-encoded, e.g. "users" as "\"users\"" and 3 as "3".
+// The panic message and stack trace fields are omitted.
+//
+// A nil err is converted to a status with codes.OK.
+func (c Converter) Status(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	code := c.code(err)
+	s := status.New(code, c.message(err, code))
+
+	kvs := errorcontext.ChainKeyValues(err)
+	if len(kvs) == 0 {
+		return s
+	}
+	metadata := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		switch kv.Key {
+		case errorcontext.FieldNamePanicStackTrace:
+			continue
+		case errorcontext.FieldNamePanicMessage:
+			if !c.ExposePanicMessage {
+				continue
+			}
+		}
+		metadata[kv.Key] = metadataValue(kv.Value)
+	}
+	reason := c.Reason
+	if reason == "" {
+		reason = codeReason(code)
+	}
+	withDetails, derr := s.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   c.Domain,
+		Metadata: metadata,
+	})
+	if derr != nil {
+		return s
+	}
+	return withDetails
+}
+
+// Err is similar to Status, returning the status as an error.
+// A nil err is returned as-is.
+func (c Converter) Err(err error) error {
+	if err == nil {
+		return nil
+	}
+	return c.Status(err).Err()
+}
+
+func (c Converter) code(err error) codes.Code {
+	for _, m := range c.Codes {
+		if errors.Is(err, m.Err) {
+			return m.Code
+		}
+	}
+	var coder interface{ Code() codes.Code }
+	if errors.As(err, &coder) {
+		return coder.Code()
+	}
+	var grpcStatus interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcStatus) {
+		return grpcStatus.GRPCStatus().Code()
+	}
+	var p errorcontext.Panic
+	if errors.As(err, &p) {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// message returns the error message, or the status message of errors carrying a status,
+// or for recovered panics, the code description unless ExposePanicMessage is set,
+// in which case the panic message is returned.
+func (c Converter) message(err error, code codes.Code) string {
+	var p errorcontext.Panic
+	if errors.As(err, &p) {
+		if c.ExposePanicMessage {
+			return p.Message
+		}
+		return code.String()
+	}
+	var grpcStatus interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcStatus) {
+		return grpcStatus.GRPCStatus().Message()
+	}
+	return err.Error()
+}
+
+// metadataValue JSON-encodes v, so that FromStatus can restore its type.
+// Values which cannot be JSON-encoded are encoded as their string representation.
+func metadataValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return string(b)
+}
+
+// codeReason converts the code name to upper snake case, e.g. NotFound to NOT_FOUND.
+func codeReason(code codes.Code) string {
+	var b strings.Builder
+	prev := ' '
+	for _, r := range code.String() {
+		if unicode.IsUpper(r) && unicode.IsLower(prev) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+		prev = r
+	}
+	return b.String()
+}
+
+// FromStatus converts a status received by a client back to an error, with the ErrorInfo metadata
+// attached as context fields. Metadata values are JSON-decoded, as encoded by Converter, with numbers
+// decoded as float64; values which are not valid JSON, e.g. set by other servers, are kept as strings.
+// The status code is retained, i.e. status.Code(err) returns s.Code().
+// A status with codes.OK is converted to a nil error.
+//
+//	if _, err := client.Call(ctx, req); err != nil {
+//		s, _ := status.FromError(err)
+//		err = grpcmw.FromStatus(s)
+//	}
+func FromStatus(s *status.Status) error {
+	if s.Code() == codes.OK {
+		return nil
+	}
+	fields := make(map[string]any)
+	for _, d := range s.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			for k, raw := range info.GetMetadata() {
+				var v any
+				if err := json.Unmarshal([]byte(raw), &v); err != nil {
+					v = raw
+				}
+				fields[k] = v
+			}
+		}
+	}
+	return errorcontext.NewBaseError(s.Err(), fields)
+}
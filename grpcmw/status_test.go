@@ -0,0 +1,199 @@
+package grpcmw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/georgepsarakis/errorcontext"
+	otlperrorcontext "github.com/georgepsarakis/errorcontext/backend/otlp"
+	zaperrorcontext "github.com/georgepsarakis/errorcontext/backend/zap"
+	zerologerrorcontext "github.com/georgepsarakis/errorcontext/backend/zerolog"
+)
+
+type codeError struct {
+	code codes.Code
+}
+
+func (e codeError) Error() string {
+	return "code error"
+}
+
+func (e codeError) Code() codes.Code {
+	return e.code
+}
+
+func TestConverter_Status(t *testing.T) {
+	t.Parallel()
+
+	panicErr := errorcontext.NewRecoverer(zaperrorcontext.FromPanic).Wrap(func() error {
+		panic("something bad happened")
+	})
+	c := Converter{
+		Codes: []CodeMapping{
+			{Err: errUnknownService, Code: codes.NotFound},
+			{Err: io.ErrUnexpectedEOF, Code: codes.Unavailable},
+		},
+		Domain: "example.com",
+	}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantMessage string
+		wantInfo    *errdetails.ErrorInfo
+	}{
+		{
+			name:     "nil",
+			err:      nil,
+			wantCode: codes.OK,
+		},
+		{
+			name: "sentinel with context fields of all backends",
+			err: fmt.Errorf("lookup: %w", zerologerrorcontext.NewError(
+				otlperrorcontext.NewError(
+					zaperrorcontext.NewError(errUnknownService, zap.String("service", "inner"), zap.Int("attempt", 3)),
+					attribute.Bool("cached", false)),
+				map[string]any{"service": "outer"})),
+			wantCode:    codes.NotFound,
+			wantMessage: "lookup: unknown service",
+			wantInfo: &errdetails.ErrorInfo{
+				Reason:   "NOT_FOUND",
+				Domain:   "example.com",
+				Metadata: map[string]string{"service": `"outer"`, "cached": "false", "attempt": "3"},
+			},
+		},
+		{
+			name:        "Code method",
+			err:         fmt.Errorf("wrapped: %w", codeError{code: codes.FailedPrecondition}),
+			wantCode:    codes.FailedPrecondition,
+			wantMessage: "wrapped: code error",
+		},
+		{
+			name:        "status error",
+			err:         fmt.Errorf("wrapped: %w", status.Error(codes.PermissionDenied, "denied")),
+			wantCode:    codes.PermissionDenied,
+			wantMessage: "denied",
+		},
+		{
+			name:        "panic",
+			err:         panicErr,
+			wantCode:    codes.Internal,
+			wantMessage: "Internal",
+			wantInfo: &errdetails.ErrorInfo{
+				Reason:   "INTERNAL",
+				Domain:   "example.com",
+				Metadata: map[string]string{"is_panic": "true"},
+			},
+		},
+		{
+			name:        "unknown",
+			err:         errors.New("failed"),
+			wantCode:    codes.Unknown,
+			wantMessage: "failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := c.Status(tt.err)
+			assert.Equal(t, tt.wantCode, s.Code())
+			assert.Equal(t, tt.wantMessage, s.Message())
+			if tt.wantInfo == nil {
+				assert.Empty(t, s.Details())
+				return
+			}
+			require.Len(t, s.Details(), 1)
+			info, ok := s.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			assert.True(t, proto.Equal(tt.wantInfo, info), "%v", info)
+		})
+	}
+}
+
+func TestConverter_Status_ExposePanicMessage(t *testing.T) {
+	t.Parallel()
+
+	err := errorcontext.NewRecoverer(zaperrorcontext.FromPanic).Wrap(func() error {
+		panic("something bad happened")
+	})
+	s := Converter{ExposePanicMessage: true}.Status(err)
+
+	assert.Equal(t, codes.Internal, s.Code())
+	assert.Equal(t, "panic: something bad happened", s.Message())
+	require.Len(t, s.Details(), 1)
+	info, ok := s.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{
+		errorcontext.FieldNamePanicMessage: `"panic: something bad happened"`,
+		"is_panic":                         "true",
+	}, info.GetMetadata())
+}
+
+func TestFromStatus(t *testing.T) {
+	t.Parallel()
+
+	c := Converter{Codes: []CodeMapping{{Err: errUnknownService, Code: codes.NotFound}}, Reason: "LOOKUP_FAILED"}
+	s := c.Status(zaperrorcontext.NewError(errUnknownService, zap.String("service", "users"), zap.Int("attempt", 3)))
+	received := status.FromProto(proto.Clone(s.Proto()).(*spb.Status))
+
+	err := FromStatus(received)
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.EqualError(t, err, "rpc error: code = NotFound desc = unknown service")
+	var berr *errorcontext.BaseError[map[string]any]
+	require.ErrorAs(t, err, &berr)
+	assert.Equal(t, map[string]any{"service": "users", "attempt": float64(3)}, berr.ContextFields())
+
+	foreign, err := status.New(codes.NotFound, "not found").WithDetails(&errdetails.ErrorInfo{
+		Metadata: map[string]string{"service": "users", "cached": "false"},
+	})
+	require.NoError(t, err)
+	require.ErrorAs(t, FromStatus(foreign), &berr)
+	assert.Equal(t, map[string]any{"service": "users", "cached": false}, berr.ContextFields())
+
+	assert.NoError(t, FromStatus(status.New(codes.OK, "")))
+}
+
+func TestConverter_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	c := Converter{Codes: []CodeMapping{{Err: errUnknownService, Code: codes.NotFound}}}
+	i := New(errorcontext.NewRecoverer(zaperrorcontext.FromPanic), nil)
+	client := newClient(t, i, grpc.ChainUnaryInterceptor(
+		func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			resp, err := handler(ctx, req)
+			return resp, c.Err(err)
+		}))
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "context"})
+	s, ok := status.FromError(err)
+	require.True(t, ok)
+	err = FromStatus(s)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	var berr *errorcontext.BaseError[map[string]any]
+	require.ErrorAs(t, err, &berr)
+	assert.Equal(t, map[string]any{"service": "context"}, berr.ContextFields())
+
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "panic"})
+	s, ok = status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.Internal, s.Code())
+	assert.Equal(t, "Internal", s.Message())
+}
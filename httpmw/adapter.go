@@ -0,0 +1,57 @@
+package httpmw
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+
+	"github.com/georgepsarakis/errorcontext"
+	"github.com/georgepsarakis/errorcontext/internal/logadapter"
+)
+
+// NewZapAdapter returns an Adapter, which logs errors as a single object with the merged
+// context of the error chain, see zaperrorcontext.Object.
+func NewZapAdapter(logger *zap.Logger) Adapter {
+	return loggerAdapter{logger: logadapter.NewZap(logger)}
+}
+
+// NewZerologAdapter returns an Adapter, which logs errors as a single object with the merged
+// context of the error chain, see zerologerrorcontext.ErrorMarshalFunc.
+func NewZerologAdapter(logger zerolog.Logger) Adapter {
+	return loggerAdapter{logger: logadapter.NewZerolog(logger)}
+}
+
+// NewSlogAdapter returns an Adapter, which logs the error message along with the merged
+// context of the error chain as the error_context group, see slogerrorcontext.AsChainContext.
+func NewSlogAdapter(logger *slog.Logger) Adapter {
+	return loggerAdapter{logger: logadapter.NewSlog(logger)}
+}
+
+type loggerAdapter struct {
+	logger logadapter.Logger
+}
+
+func (a loggerAdapter) WithFields(err error, info RequestInfo) error {
+	return a.logger.WithFields(err, info.fields())
+}
+
+func (a loggerAdapter) Log(ctx context.Context, err error) {
+	a.logger.Log(ctx, LogMessage, err)
+}
+
+// fields maps the request information to context fields, omitting the route and request ID if empty.
+func (info RequestInfo) fields() []errorcontext.KeyValue {
+	fields := []errorcontext.KeyValue{
+		{Key: FieldNameMethod, Value: info.Method},
+		{Key: FieldNameRemoteAddr, Value: info.RemoteAddr},
+	}
+	if info.Route != "" {
+		fields = append(fields, errorcontext.KeyValue{Key: FieldNameRoute, Value: info.Route})
+	}
+	if info.RequestID != "" {
+		fields = append(fields, errorcontext.KeyValue{Key: FieldNameRequestID, Value: info.RequestID})
+	}
+	return fields
+}
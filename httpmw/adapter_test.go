@@ -0,0 +1,101 @@
+package httpmw
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/georgepsarakis/errorcontext"
+	slogerrorcontext "github.com/georgepsarakis/errorcontext/backend/slog"
+	zaperrorcontext "github.com/georgepsarakis/errorcontext/backend/zap"
+	zerologerrorcontext "github.com/georgepsarakis/errorcontext/backend/zerolog"
+)
+
+func serve(t *testing.T, m *Middleware) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", func(http.ResponseWriter, *http.Request) {
+		panic("something bad happened")
+	})
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(DefaultRequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	m.Handler(mux).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func assertContext(t *testing.T, context map[string]any) {
+	t.Helper()
+
+	assert.Equal(t, http.MethodPost, context[FieldNameMethod])
+	assert.Equal(t, "POST /orders", context[FieldNameRoute])
+	assert.Equal(t, "192.0.2.1:1234", context[FieldNameRemoteAddr])
+	assert.Equal(t, "req-1", context[FieldNameRequestID])
+	assert.Equal(t, "panic: something bad happened", context[errorcontext.FieldNamePanicMessage])
+	assert.Equal(t, true, context["is_panic"])
+	assert.NotEmpty(t, context[errorcontext.FieldNamePanicStackTrace])
+}
+
+func TestNewZapAdapter(t *testing.T) {
+	t.Parallel()
+
+	core, observedLogs := observer.New(zap.InfoLevel)
+	serve(t, New(errorcontext.NewRecoverer(zaperrorcontext.FromPanic), NewZapAdapter(zap.New(core))))
+
+	logs := observedLogs.All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, LogMessage, logs[0].Message)
+	obj, ok := logs[0].ContextMap()["error"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "panic: something bad happened", obj["message"])
+	context, ok := obj["context"].(map[string]any)
+	require.True(t, ok)
+	assertContext(t, context)
+}
+
+func TestNewZerologAdapter(t *testing.T) {
+	t.Parallel()
+
+	var output bytes.Buffer
+	serve(t, New(errorcontext.NewRecoverer(zerologerrorcontext.FromPanic), NewZerologAdapter(zerolog.New(&output))))
+
+	var record struct {
+		Message string `json:"message"`
+		Error   struct {
+			Message string         `json:"message"`
+			Context map[string]any `json:"context"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, LogMessage, record.Message)
+	assert.Equal(t, "panic: something bad happened", record.Error.Message)
+	assertContext(t, record.Error.Context)
+}
+
+func TestNewSlogAdapter(t *testing.T) {
+	t.Parallel()
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&output, nil))
+	serve(t, New(errorcontext.NewRecoverer(slogerrorcontext.FromPanic), NewSlogAdapter(logger)))
+
+	var record struct {
+		Msg          string         `json:"msg"`
+		Error        string         `json:"error"`
+		ErrorContext map[string]any `json:"error_context"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, LogMessage, record.Msg)
+	assert.Equal(t, "panic: something bad happened", record.Error)
+	assertContext(t, record.ErrorContext)
+}
@@ -0,0 +1,178 @@
+// Package httpmw provides net/http middleware, which recovers panics in handlers
+// through an errorcontext.Recoverer, logs them in structured form along with the request
+// information and responds with an Internal Server Error, if the response has not been started.
+package httpmw
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+const (
+	FieldNameMethod     = "method"
+	FieldNameRoute      = "route"
+	FieldNameRemoteAddr = "remote_addr"
+	FieldNameRequestID  = "request_id"
+)
+
+// DefaultRequestIDHeader is the request header containing the request ID.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// RequestInfo describes the request during which a panic was recovered.
+type RequestInfo struct {
+	Method string
+	// Route is the pattern of the http.ServeMux route that matched the request, if any.
+	Route      string
+	RemoteAddr string
+	RequestID  string
+}
+
+// Adapter attaches the request information to errors and logs them,
+// see NewZapAdapter, NewZerologAdapter and NewSlogAdapter.
+// The adapter backend should match the error generator of the Recoverer, e.g. zaperrorcontext.FromPanic
+// for NewZapAdapter, so that the panic context is rendered along with the request information.
+type Adapter interface {
+	// WithFields returns err with the request information attached as error context.
+	WithFields(err error, info RequestInfo) error
+	// Log logs the error of a recovered panic.
+	Log(ctx context.Context, err error)
+}
+
+// LogMessage is the message of log records for recovered panics.
+const LogMessage = "recovered panic in HTTP handler"
+
+// Middleware recovers panics in HTTP handlers.
+type Middleware struct {
+	// RequestIDHeader is the request header containing the request ID.
+	// New sets DefaultRequestIDHeader by default.
+	RequestIDHeader string
+	// Problems if set renders the response for recovered panics as problem details,
+	// instead of a plain text response, see ProblemRenderer.
+	Problems *ProblemRenderer
+
+	wrap    func(ctx context.Context, fn func(context.Context) error) error
+	adapter Adapter
+}
+
+// New returns a Middleware, which runs handlers through the given Recoverer
+// and logs recovered panics with adapter. If adapter is nil, recovered panics are only
+// responded to, e.g. when logged by Recoverer.OnPanic callbacks.
+func New[T error](r errorcontext.Recoverer[T], adapter Adapter) *Middleware {
+	return &Middleware{
+		RequestIDHeader: DefaultRequestIDHeader,
+		wrap:            r.WrapContext,
+		adapter:         adapter,
+	}
+}
+
+// Handler wraps next, so that panics are converted to errors with the request information attached
+// and logged with the configured Adapter. A 500 Internal Server Error response is written,
+// unless the response headers have already been sent.
+// Panics with http.ErrAbortHandler are not logged and are raised again, so that the server aborts
+// the response without logging, as intended by net/http.
+//
+//	mux := http.NewServeMux()
+//	// ...
+//	server := &http.Server{Handler: httpmw.New(recoverer, httpmw.NewSlogAdapter(logger)).Handler(mux)}
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		err := m.wrap(r.Context(), func(context.Context) error {
+			next.ServeHTTP(rw, r)
+			return nil
+		})
+		if err == nil {
+			return
+		}
+		if errors.Is(err, http.ErrAbortHandler) {
+			panic(http.ErrAbortHandler)
+		}
+		if m.adapter != nil {
+			err = m.adapter.WithFields(err, m.requestInfo(r))
+			m.adapter.Log(r.Context(), err)
+		}
+		if rw.wroteHeader {
+			return
+		}
+		if m.Problems != nil {
+			m.Problems.Render(w, r, err)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	})
+}
+
+func (m *Middleware) requestInfo(r *http.Request) RequestInfo {
+	info := RequestInfo{
+		Method: r.Method,
+		// The pattern is set by http.ServeMux when routing the request.
+		Route:      r.Pattern,
+		RemoteAddr: r.RemoteAddr,
+	}
+	if m.RequestIDHeader != "" {
+		info.RequestID = r.Header.Get(m.RequestIDHeader)
+	}
+	return info
+}
+
+// responseWriter tracks whether the response headers have been sent.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	// Informational responses do not finalize the response headers.
+	if code >= http.StatusOK {
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// FlushError flushes the underlying http.ResponseWriter, if supported,
+// which also sends the response headers. It is used by http.ResponseController.
+func (w *responseWriter) FlushError() error {
+	err := http.NewResponseController(w.ResponseWriter).Flush()
+	if !errors.Is(err, http.ErrNotSupported) {
+		w.wroteHeader = true
+	}
+	return err
+}
+
+func (w *responseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// Hijack takes over the connection of the underlying http.ResponseWriter, if supported.
+// No response can be sent by the middleware after the connection has been hijacked.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// ReadFrom allows the underlying http.ResponseWriter to use its io.ReaderFrom implementation, e.g. sendfile.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.wroteHeader = true
+	return io.Copy(w.ResponseWriter, r)
+}
+
+// Unwrap allows http.ResponseController to access the underlying http.ResponseWriter,
+// e.g. in order to set deadlines. Writing to the underlying http.ResponseWriter directly
+// is not tracked, so the middleware may still respond to a panic afterwards.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
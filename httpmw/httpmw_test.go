@@ -0,0 +1,212 @@
+package httpmw
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/georgepsarakis/errorcontext"
+	"github.com/georgepsarakis/errorcontext/internal/logadapter/logadaptertest"
+)
+
+func TestMiddleware_Handler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+		wantLogged bool
+	}{
+		{
+			name: "recovers panics",
+			handler: func(http.ResponseWriter, *http.Request) {
+				panic("something bad happened")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error\n",
+			wantLogged: true,
+		},
+		{
+			name: "does not write a response after headers have been sent",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte("partial"))
+				panic("something bad happened")
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   "partial",
+			wantLogged: true,
+		},
+		{
+			name: "passes through responses",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			adapter := &logadaptertest.Recorder[RequestInfo]{}
+			mux := http.NewServeMux()
+			mux.Handle("GET /users/{id}", tt.handler)
+			handler := New(errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator), adapter).Handler(mux)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			req.Header.Set(DefaultRequestIDHeader, "req-1")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+			if !tt.wantLogged {
+				assert.Empty(t, adapter.Errs())
+				return
+			}
+			require.Len(t, adapter.Errs(), 1)
+			var p errorcontext.Panic
+			require.ErrorAs(t, adapter.Errs()[0], &p)
+			assert.Equal(t, "panic: something bad happened", p.Message)
+			assert.Equal(t, []RequestInfo{{
+				Method:     http.MethodGet,
+				Route:      "GET /users/{id}",
+				RemoteAddr: "192.0.2.1:1234",
+				RequestID:  "req-1",
+			}}, adapter.Info())
+		})
+	}
+}
+
+func TestMiddleware_Handler_AbortHandler(t *testing.T) {
+	t.Parallel()
+
+	adapter := &logadaptertest.Recorder[RequestInfo]{}
+	handler := New(errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator), adapter).Handler(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Empty(t, adapter.Errs())
+	assert.Empty(t, adapter.Info())
+}
+
+func TestMiddleware_Handler_NilAdapter(t *testing.T) {
+	t.Parallel()
+
+	handler := New(errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator), nil).Handler(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("something bad happened")
+		}))
+
+	rec := httptest.NewRecorder()
+	require.NotPanics(t, func() {
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestResponseWriter_Unwrap(t *testing.T) {
+	t.Parallel()
+
+	adapter := &logadaptertest.Recorder[RequestInfo]{}
+	handler := New(errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator), adapter).Handler(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				panic(err)
+			}
+			panic("something bad happened")
+		}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.True(t, rec.Flushed)
+	require.Len(t, adapter.Errs(), 1)
+	assert.False(t, errors.Is(adapter.Errs()[0], http.ErrNotSupported))
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestMiddleware_Handler_ResponseStarted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		start    func(w http.ResponseWriter)
+		wantBody string
+	}{
+		{
+			name: "flushed through http.ResponseController",
+			start: func(w http.ResponseWriter) {
+				if err := http.NewResponseController(w).Flush(); err != nil {
+					panic(err)
+				}
+			},
+		},
+		{
+			name: "flushed through http.Flusher",
+			start: func(w http.ResponseWriter) {
+				w.(http.Flusher).Flush()
+			},
+		},
+		{
+			name: "copied through io.ReaderFrom",
+			start: func(w http.ResponseWriter) {
+				if _, err := w.(io.ReaderFrom).ReadFrom(strings.NewReader("partial")); err != nil {
+					panic(err)
+				}
+			},
+			wantBody: "partial",
+		},
+		{
+			name: "hijacked",
+			start: func(w http.ResponseWriter) {
+				if _, _, err := http.NewResponseController(w).Hijack(); err != nil {
+					panic(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			adapter := &logadaptertest.Recorder[RequestInfo]{}
+			handler := New(errorcontext.NewRecoverer(errorcontext.DefaultErrorGenerator), adapter).Handler(
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					tt.start(w)
+					panic("something bad happened")
+				}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(hijackRecorder{rec}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			require.Len(t, adapter.Errs(), 1)
+			assert.ErrorContains(t, adapter.Errs()[0], "something bad happened")
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
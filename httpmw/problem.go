@@ -0,0 +1,138 @@
+package httpmw
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/georgepsarakis/errorcontext"
+)
+
+// ProblemContentType is the media type of problem details, see RFC 9457.
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details object, see RFC 9457.
+type Problem struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	// Extensions are rendered as additional members.
+	// Extensions named after one of the standard members are ignored.
+	Extensions map[string]any
+}
+
+// MarshalJSON implements json.Marshaler, rendering the extensions as members of the problem object.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	maps.Copy(members, p.Extensions)
+	delete(members, "type")
+	delete(members, "title")
+	delete(members, "status")
+	delete(members, "detail")
+	delete(members, "instance")
+	if p.Type != "" {
+		members["type"] = p.Type
+	}
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// ProblemMapping maps errors matching Err, see errors.Is, to a problem type.
+type ProblemMapping struct {
+	Err error
+	// Status is the HTTP status code of the response. Zero or any status code other than
+	// a client or server error (4xx or 5xx) is replaced with 500 Internal Server Error.
+	Status int
+	// Type is a URI reference identifying the problem type. By default, it is about:blank.
+	Type string
+	// Title is a short summary of the problem type. By default, it is the HTTP status text.
+	Title string
+}
+
+// ProblemRenderer renders error chains as problem details, see RFC 9457.
+// Only the allowed context fields are rendered, all other fields are kept server-side.
+type ProblemRenderer struct {
+	// Mappings are evaluated in order, the first mapping with a matching error determines the problem type.
+	// Errors without a matching mapping are rendered as 500 Internal Server Error.
+	Mappings []ProblemMapping
+	// AllowedFields are the keys of the context fields rendered as extension members,
+	// for errors of any backend, see errorcontext.ChainKeyValues.
+	// The panic stack trace is never rendered, even if allowed.
+	AllowedFields []string
+}
+
+// Problem converts err to problem details for the request r.
+// The error message is rendered as the detail member only for client errors (4xx status codes),
+// since the message of server errors, e.g. recovered panics, may expose internal information.
+// The instance member is the request path.
+func (pr ProblemRenderer) Problem(r *http.Request, err error) Problem {
+	p := Problem{Status: http.StatusInternalServerError}
+	var panicErr errorcontext.Panic
+	if !errors.As(err, &panicErr) {
+		for _, m := range pr.Mappings {
+			if errors.Is(err, m.Err) {
+				p = Problem{Status: m.Status, Type: m.Type, Title: m.Title}
+				break
+			}
+		}
+	}
+	if p.Status < 400 || p.Status > 599 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Status >= 400 && p.Status < 500 {
+		p.Detail = err.Error()
+	}
+	if r != nil && r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+	for _, kv := range errorcontext.ChainKeyValues(err) {
+		if kv.Key == errorcontext.FieldNamePanicStackTrace || !slices.Contains(pr.AllowedFields, kv.Key) {
+			continue
+		}
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]any)
+		}
+		p.Extensions[kv.Key] = kv.Value
+	}
+	return p
+}
+
+// Render writes err as an application/problem+json response, see Problem.
+// Content-Length and Content-Encoding headers already set, e.g. by a handler before panicking,
+// are removed, since they do not apply to the problem details.
+func (pr ProblemRenderer) Render(w http.ResponseWriter, r *http.Request, err error) {
+	p := pr.Problem(r, err)
+	b, jerr := json.Marshal(p)
+	if jerr != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	h.Set("Content-Type", ProblemContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,199 @@
+package httpmw
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"github.com/georgepsarakis/errorcontext"
+	otlperrorcontext "github.com/georgepsarakis/errorcontext/backend/otlp"
+	slogerrorcontext "github.com/georgepsarakis/errorcontext/backend/slog"
+	zaperrorcontext "github.com/georgepsarakis/errorcontext/backend/zap"
+	zerologerrorcontext "github.com/georgepsarakis/errorcontext/backend/zerolog"
+)
+
+var (
+	errOutOfCredit = errors.New("out of credit")
+	errDatabase    = errors.New("database unavailable")
+)
+
+func TestProblemRenderer_Problem(t *testing.T) {
+	t.Parallel()
+
+	pr := ProblemRenderer{
+		Mappings: []ProblemMapping{
+			{Err: errOutOfCredit, Status: http.StatusForbidden, Type: "https://example.com/probs/out-of-credit", Title: "You do not have enough credit."},
+			{Err: errDatabase, Status: http.StatusServiceUnavailable},
+		},
+		AllowedFields: []string{"balance", "account", "tenant", "type", errorcontext.FieldNamePanicStackTrace},
+	}
+	panicErr := errorcontext.NewRecoverer(zaperrorcontext.FromPanic).Wrap(func() error {
+		panic(errOutOfCredit)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/account/12345/msgs/abc?secret=1", nil)
+
+	tests := []struct {
+		name string
+		err  error
+		want Problem
+	}{
+		{
+			name: "zap",
+			err: fmt.Errorf("transfer: %w", zaperrorcontext.NewError(errOutOfCredit,
+				zap.Int("balance", 30), zap.String("account", "/account/12345"), zap.String("query", "SELECT 1"))),
+			want: Problem{
+				Type:       "https://example.com/probs/out-of-credit",
+				Title:      "You do not have enough credit.",
+				Status:     http.StatusForbidden,
+				Detail:     "transfer: out of credit",
+				Instance:   "/account/12345/msgs/abc",
+				Extensions: map[string]any{"balance": int64(30), "account": "/account/12345"},
+			},
+		},
+		{
+			name: "zerolog, otlp and slog",
+			err: zerologerrorcontext.NewError(
+				otlperrorcontext.NewError(
+					slogerrorcontext.NewError(errOutOfCredit, slog.Int("balance", 30), slog.String("tenant", "inner")),
+					attribute.String("tenant", "acme")),
+				map[string]any{"account": "/account/12345", "type": "ignored"}),
+			want: Problem{
+				Type:       "https://example.com/probs/out-of-credit",
+				Title:      "You do not have enough credit.",
+				Status:     http.StatusForbidden,
+				Detail:     "out of credit",
+				Instance:   "/account/12345/msgs/abc",
+				Extensions: map[string]any{"balance": int64(30), "account": "/account/12345", "tenant": "acme", "type": "ignored"},
+			},
+		},
+		{
+			name: "server error without detail",
+			err:  fmt.Errorf("sql: %w", errDatabase),
+			want: Problem{
+				Type:     "about:blank",
+				Title:    "Service Unavailable",
+				Status:   http.StatusServiceUnavailable,
+				Instance: "/account/12345/msgs/abc",
+			},
+		},
+		{
+			name: "panic",
+			err:  panicErr,
+			want: Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Instance: "/account/12345/msgs/abc",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, pr.Problem(req, tt.err))
+		})
+	}
+}
+
+func TestProblem_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(Problem{
+		Type:       "about:blank",
+		Title:      "Forbidden",
+		Status:     http.StatusForbidden,
+		Extensions: map[string]any{"balance": 30, "type": "ignored", "detail": "ignored"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Forbidden","status":403,"balance":30}`, string(b))
+}
+
+func TestProblemRenderer_Render(t *testing.T) {
+	t.Parallel()
+
+	pr := ProblemRenderer{AllowedFields: []string{FieldNameRequestID, FieldNameRoute}}
+	m := New(errorcontext.NewRecoverer(zaperrorcontext.FromPanic), NewZapAdapter(zap.NewNop()))
+	m.Problems = &pr
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(http.ResponseWriter, *http.Request) {
+		panic("something bad happened")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(DefaultRequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	m.Handler(mux).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+  "type": "about:blank",
+  "title": "Internal Server Error",
+  "status": 500,
+  "instance": "/users/1",
+  "request_id": "req-1",
+  "route": "GET /users/{id}"
+}`, rec.Body.String())
+}
+
+func TestProblemRenderer_Render_InvalidStatus(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range []int{0, http.StatusOK, 1000} {
+		t.Run(fmt.Sprint(status), func(t *testing.T) {
+			t.Parallel()
+
+			pr := ProblemRenderer{Mappings: []ProblemMapping{{Err: errOutOfCredit, Status: status}}}
+			rec := httptest.NewRecorder()
+			require.NotPanics(t, func() {
+				pr.Render(rec, httptest.NewRequest(http.MethodGet, "/orders", nil), errOutOfCredit)
+			})
+
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.JSONEq(t, `{
+  "type": "about:blank",
+  "title": "Internal Server Error",
+  "status": 500,
+  "instance": "/orders"
+}`, rec.Body.String())
+		})
+	}
+}
+
+func TestProblemRenderer_Render_StaleHeaders(t *testing.T) {
+	t.Parallel()
+
+	m := New(errorcontext.NewRecoverer(zaperrorcontext.FromPanic), NewZapAdapter(zap.NewNop()))
+	m.Problems = &ProblemRenderer{}
+	server := httptest.NewServer(m.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		w.Header().Set("Content-Encoding", "gzip")
+		panic("something bad happened")
+	})))
+	t.Cleanup(server.Close)
+
+	resp, err := server.Client().Get(server.URL + "/orders")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	assert.JSONEq(t, `{
+  "type": "about:blank",
+  "title": "Internal Server Error",
+  "status": 500,
+  "instance": "/orders"
+}`, string(body))
+}
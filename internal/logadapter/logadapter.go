@@ -0,0 +1,99 @@
+// Package logadapter implements the zap, zerolog and slog adapters shared by the middleware packages,
+// which attach the fields describing a request to errors and log recovered panics.
+// The middleware packages only map their request information to fields.
+package logadapter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+
+	"github.com/georgepsarakis/errorcontext"
+	slogerrorcontext "github.com/georgepsarakis/errorcontext/backend/slog"
+	zaperrorcontext "github.com/georgepsarakis/errorcontext/backend/zap"
+	zerologerrorcontext "github.com/georgepsarakis/errorcontext/backend/zerolog"
+)
+
+// Logger attaches fields to errors and logs them with a backend logger.
+type Logger interface {
+	// WithFields returns err with the fields attached as error context.
+	WithFields(err error, fields []errorcontext.KeyValue) error
+	// Log logs the error of a recovered panic with the given message.
+	Log(ctx context.Context, message string, err error)
+}
+
+// NewZap returns a Logger, which logs errors as a single object with the merged
+// context of the error chain, see zaperrorcontext.Object.
+func NewZap(logger *zap.Logger) Logger {
+	return zapLogger{logger: logger}
+}
+
+type zapLogger struct {
+	logger *zap.Logger
+}
+
+func (l zapLogger) WithFields(err error, fields []errorcontext.KeyValue) error {
+	zfs := make([]zap.Field, 0, len(fields))
+	for _, f := range fields {
+		zfs = append(zfs, zap.Any(f.Key, f.Value))
+	}
+	return zaperrorcontext.NewError(err, zfs...)
+}
+
+func (l zapLogger) Log(_ context.Context, message string, err error) {
+	l.logger.Error(message, zaperrorcontext.Object("error", err))
+}
+
+// NewZerolog returns a Logger, which logs errors as a single object with the merged
+// context of the error chain, see zerologerrorcontext.ErrorMarshalFunc.
+func NewZerolog(logger zerolog.Logger) Logger {
+	return zerologLogger{logger: logger}
+}
+
+type zerologLogger struct {
+	logger zerolog.Logger
+}
+
+func (l zerologLogger) WithFields(err error, fields []errorcontext.KeyValue) error {
+	m := make(map[string]any, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+	return zerologerrorcontext.NewError(err, m)
+}
+
+func (l zerologLogger) Log(ctx context.Context, message string, err error) {
+	event := l.logger.Error().Ctx(ctx)
+	if m, ok := zerologerrorcontext.ErrorMarshalFunc(nil)(err).(zerolog.LogObjectMarshaler); ok {
+		event = event.Object(zerolog.ErrorFieldName, m)
+	} else {
+		event = event.Err(err)
+	}
+	event.Msg(message)
+}
+
+// NewSlog returns a Logger, which logs the error message along with the merged
+// context of the error chain as the error_context group, see slogerrorcontext.AsChainContext.
+func NewSlog(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) WithFields(err error, fields []errorcontext.KeyValue) error {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	return slogerrorcontext.NewError(err, attrs...)
+}
+
+func (l slogLogger) Log(ctx context.Context, message string, err error) {
+	l.logger.LogAttrs(ctx, slog.LevelError, message,
+		slog.String("error", err.Error()),
+		slog.Any("error_context", slog.GroupValue(slogerrorcontext.AsChainContext(err)...)))
+}
@@ -0,0 +1,45 @@
+// Package logadaptertest provides a recording adapter for testing the middleware packages.
+package logadaptertest
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Recorder records the request information and the errors passed to the adapter of a middleware,
+// where I is the request information type, e.g. httpmw.RequestInfo.
+type Recorder[I any] struct {
+	mu   sync.Mutex
+	info []I
+	errs []error
+}
+
+// WithFields records info and returns err as-is.
+func (r *Recorder[I]) WithFields(err error, info I) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.info = append(r.info, info)
+	return err
+}
+
+// Log records err.
+func (r *Recorder[I]) Log(_ context.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+// Info returns the recorded request information.
+func (r *Recorder[I]) Info() []I {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.info)
+}
+
+// Errs returns the recorded errors.
+func (r *Recorder[I]) Errs() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.errs)
+}
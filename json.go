@@ -0,0 +1,96 @@
+package errorcontext
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// jsonError is the JSON representation of errors carrying context.
+type jsonError struct {
+	Message string          `json:"message"`
+	Context any             `json:"context,omitempty"`
+	IsPanic bool            `json:"is_panic"`
+	Cause   json.RawMessage `json:"cause,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, producing an object with the following members:
+//   - message: the error message.
+//   - context: the attached context.
+//   - is_panic: whether the error has been marked as a panic.
+//   - cause: the JSON representation of the next error in the chain
+//     that implements json.Marshaler, if any.
+//
+// See Unmarshal for the reverse operation.
+func (e *BaseError[T]) MarshalJSON() ([]byte, error) {
+	return e.marshalJSON(e.ContextFields())
+}
+
+// MarshalKeyValuesJSON is intended for the json.Marshaler implementation of backend errors,
+// rendering the given context fields as the members of the context object.
+func (e *BaseError[T]) MarshalKeyValuesJSON(kvs []KeyValue) ([]byte, error) {
+	if len(kvs) == 0 {
+		return e.marshalJSON(nil)
+	}
+	context := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		context[kv.Key] = kv.Value
+	}
+	return e.marshalJSON(context)
+}
+
+func (e *BaseError[T]) marshalJSON(context any) ([]byte, error) {
+	je := jsonError{
+		Message: e.Error(),
+		Context: context,
+		IsPanic: e.IsPanic(),
+	}
+	var cause json.Marshaler
+	if errors.As(e.originalErr, &cause) {
+		b, err := cause.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		je.Cause = b
+	}
+	return json.Marshal(je)
+}
+
+// Unmarshal reconstructs an error from its JSON representation, as produced by MarshalJSON.
+// The context is decoded to a generic map and nested causes are reconstructed recursively,
+// so that they can be found with errors.As & Collect.
+func Unmarshal(data []byte) (*BaseError[map[string]any], error) {
+	var je struct {
+		Message string          `json:"message"`
+		Context map[string]any  `json:"context"`
+		IsPanic bool            `json:"is_panic"`
+		Cause   json.RawMessage `json:"cause"`
+	}
+	if err := json.Unmarshal(data, &je); err != nil {
+		return nil, err
+	}
+	original := &unmarshaledError{message: je.Message}
+	if len(je.Cause) > 0 {
+		cause, err := Unmarshal(je.Cause)
+		if err != nil {
+			return nil, err
+		}
+		original.cause = cause
+	}
+	e := NewBaseError(error(original), je.Context)
+	e.isPanic = je.IsPanic
+	return e, nil
+}
+
+// unmarshaledError retains the message of a reconstructed error, along with its reconstructed cause.
+type unmarshaledError struct {
+	message string
+	cause   error
+}
+
+func (e *unmarshaledError) Error() string {
+	return e.message
+}
+
+func (e *unmarshaledError) Unwrap() error {
+	return e.cause
+}
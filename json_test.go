@@ -0,0 +1,92 @@
+package errorcontext
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kvError struct {
+	*BaseError[[]KeyValue]
+}
+
+func (e *kvError) MarshalJSON() ([]byte, error) {
+	return e.MarshalKeyValuesJSON(e.KeyValues())
+}
+
+func (e *kvError) KeyValues() []KeyValue {
+	return e.ContextFields()
+}
+
+func TestBaseError_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	inner := &kvError{
+		BaseError: NewBaseError(errors.New("connection reset"), []KeyValue{
+			{Key: "host", Value: "db-1"},
+		}),
+	}
+	outer := &kvError{
+		BaseError: NewBaseError(fmt.Errorf("query failed: %w", inner), []KeyValue{
+			{Key: "request_id", Value: "req-1"},
+			{Key: "attempt", Value: 3},
+		}),
+	}
+	outer.MarkAsPanic()
+
+	b, err := json.Marshal(outer)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "message": "query failed: connection reset",
+  "context": {"request_id": "req-1", "attempt": 3},
+  "is_panic": true,
+  "cause": {
+    "message": "connection reset",
+    "context": {"host": "db-1"},
+    "is_panic": false
+  }
+}`, string(b))
+
+	b, err = json.Marshal(&kvError{BaseError: NewBaseError[[]KeyValue](errors.New("no context"), nil)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message": "no context", "is_panic": false}`, string(b))
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{
+  "message": "query failed: connection reset",
+  "context": {"request_id": "req-1", "attempt": 3},
+  "is_panic": true,
+  "cause": {
+    "message": "connection reset",
+    "context": {"host": "db-1"},
+    "is_panic": false
+  }
+}`)
+
+	e, err := Unmarshal(data)
+	require.NoError(t, err)
+	assert.EqualError(t, e, "query failed: connection reset")
+	assert.True(t, e.IsPanic())
+	assert.Equal(t, map[string]any{"request_id": "req-1", "attempt": float64(3)}, e.ContextFields())
+
+	chain := Collect[*BaseError[map[string]any]](e)
+	require.Len(t, chain, 2)
+	assert.EqualError(t, chain[1], "connection reset")
+	assert.False(t, chain[1].IsPanic())
+	assert.Equal(t, map[string]any{"host": "db-1"}, chain[1].ContextFields())
+
+	// Reconstructed errors can be marshaled again.
+	b, err := json.Marshal(e)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(b))
+
+	_, err = Unmarshal([]byte(`{"message":`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package errorcontext
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+// RepanicPolicy decides whether a recovered panic must be raised again,
+// after it has been converted to an error and the OnPanic callbacks have been invoked.
+type RepanicPolicy func(Panic) bool
+
+// RepanicOn re-raises panics with an error value matching any of the targets, see errors.Is.
+func RepanicOn(targets ...error) RepanicPolicy {
+	return func(p Panic) bool {
+		for _, target := range targets {
+			if errors.Is(p, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RepanicOnRuntimeError re-raises panics caused by run-time errors, e.g. nil pointer dereferences,
+// which indicate that the program state may not be safe to continue from.
+func RepanicOnRuntimeError(p Panic) bool {
+	var rerr runtime.Error
+	return errors.As(p, &rerr)
+}
+
+// RepanicAfter re-raises every panic after the first n panics have been recovered.
+// The budget is shared by all Recoverer values using the returned policy.
+func RepanicAfter(n uint64) RepanicPolicy {
+	var count atomic.Uint64
+	return func(Panic) bool {
+		return count.Add(1) > n
+	}
+}
+
+// RepanicAny re-raises panics for which any of the policies returns true.
+// Policies are evaluated in order, until the first match.
+func RepanicAny(policies ...RepanicPolicy) RepanicPolicy {
+	return func(p Panic) bool {
+		for _, policy := range policies {
+			if policy(p) {
+				return true
+			}
+		}
+		return false
+	}
+}
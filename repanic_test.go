@@ -0,0 +1,82 @@
+package errorcontext
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepanicPolicies(t *testing.T) {
+	t.Parallel()
+
+	var nilMap map[string]int
+	var nilMapErr error
+	func() {
+		defer func() {
+			nilMapErr = recover().(error)
+		}()
+		nilMap["a"]++
+	}()
+
+	tests := []struct {
+		name   string
+		policy RepanicPolicy
+		value  any
+		want   bool
+	}{
+		{name: "on target", policy: RepanicOn(io.EOF, io.ErrUnexpectedEOF), value: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "on target not matching", policy: RepanicOn(io.EOF), value: io.ErrUnexpectedEOF, want: false},
+		{name: "on target with string value", policy: RepanicOn(io.EOF), value: "EOF", want: false},
+		{name: "on runtime error", policy: RepanicOnRuntimeError, value: nilMapErr, want: true},
+		{name: "on runtime error with error value", policy: RepanicOnRuntimeError, value: io.EOF, want: false},
+		{name: "any", policy: RepanicAny(RepanicOn(io.EOF), RepanicOnRuntimeError), value: nilMapErr, want: true},
+		{name: "any without policies", policy: RepanicAny(), value: io.EOF, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.policy(Panic{Value: tt.value}))
+		})
+	}
+
+	t.Run("after", func(t *testing.T) {
+		t.Parallel()
+
+		policy := RepanicAfter(2)
+		assert.False(t, policy(Panic{}))
+		assert.False(t, policy(Panic{}))
+		assert.True(t, policy(Panic{}))
+		assert.True(t, policy(Panic{}))
+	})
+}
+
+func TestRecoverer_Repanic(t *testing.T) {
+	t.Parallel()
+
+	errFatal := errors.New("fatal")
+	var observed []error
+	r := NewRecoverer(DefaultErrorGenerator)
+	r.OnPanic = []func(Panic, error){
+		func(_ Panic, err error) {
+			observed = append(observed, err)
+		},
+	}
+	r.Repanic = RepanicOn(errFatal)
+
+	err := r.Wrap(func() error {
+		panic(io.EOF)
+	})
+	assert.ErrorIs(t, err, io.EOF)
+
+	assert.PanicsWithValue(t, errFatal, func() {
+		_ = r.Wrap(func() error {
+			panic(errFatal)
+		})
+	})
+	if assert.Len(t, observed, 2) {
+		assert.ErrorIs(t, observed[1], errFatal)
+	}
+}
@@ -0,0 +1,164 @@
+package errorcontext
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Frame is a single resolved stack frame of a goroutine stack trace.
+type Frame struct {
+	// Function is the fully-qualified function name, e.g. github.com/org/repo/pkg.(*Type).Method.
+	Function string `json:"func"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	// Package is the import path of the package declaring Function.
+	Package string `json:"-"`
+	// PC is the program counter of the frame.
+	PC uintptr `json:"-"`
+}
+
+// String formats the frame in the same layout used by Go tracebacks:
+// the function name, followed by the file:line location on a tab-indented line.
+func (f Frame) String() string {
+	return fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+// callers returns the stack frames of the calling goroutine, skipping the
+// given number of frames in addition to callers itself.
+func callers(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := make([]Frame, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	for {
+		f, more := iter.Next()
+		frames = append(frames, Frame{
+			Function: f.Function,
+			File:     f.File,
+			Line:     f.Line,
+			Package:  packageName(f.Function),
+			PC:       f.PC,
+		})
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// packageName extracts the package import path from a fully-qualified function name.
+// The runtime escapes dots in the last element of the import path, e.g. gopkg.in/yaml%2ev3.Marshal,
+// which are unescaped. Unescaped names with a major version suffix, e.g. gopkg.in/yaml.v3.Marshal,
+// are also supported.
+func packageName(function string) string {
+	lastSlash := max(strings.LastIndexByte(function, '/'), 0)
+	last := function[lastSlash:]
+	i := strings.IndexByte(last, '.')
+	if i < 0 {
+		return function
+	}
+	for isVersionSuffix(last[i+1:]) {
+		i += 1 + strings.IndexByte(last[i+1:], '.')
+	}
+	return strings.ReplaceAll(function[:lastSlash+i], "%2e", ".")
+}
+
+// isVersionSuffix reports whether s starts with a major version suffix followed by a dot, e.g. v3.
+func isVersionSuffix(s string) bool {
+	if len(s) < 3 || s[0] != 'v' {
+		return false
+	}
+	i := 1
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i > 1 && i < len(s) && s[i] == '.'
+}
+
+// FrameFilter decides whether a stack frame is retained in a Panic stack trace.
+// A frame is retained only if it returns true.
+type FrameFilter func(f Frame) bool
+
+// ownModule is the module path, i.e. the import path of this package; frames of
+// the module packages (including the backends and middleware) are always filtered out.
+var ownModule = reflect.TypeFor[Frame]().PkgPath()
+
+func isOwnFrame(f Frame) bool {
+	return f.Package == ownModule || strings.HasPrefix(f.Package, ownModule+"/")
+}
+
+// DropRuntimeFrames filters out frames of the runtime panic machinery,
+// i.e. runtime/panic.go, the signal handlers converting faults to panics and runtime/debug.
+func DropRuntimeFrames(f Frame) bool {
+	switch f.Package {
+	case "runtime":
+		base := path.Base(f.File)
+		return base != "panic.go" && !strings.HasPrefix(base, "signal_")
+	case "runtime/debug":
+		return false
+	}
+	return true
+}
+
+var _ FrameFilter = DropRuntimeFrames
+
+// IncludePackages retains only frames whose package import path starts with one of the given prefixes.
+func IncludePackages(prefixes ...string) FrameFilter {
+	return func(f Frame) bool {
+		return hasAnyPrefix(f.Package, prefixes)
+	}
+}
+
+// ExcludePackages filters out frames whose package import path starts with one of the given prefixes.
+func ExcludePackages(prefixes ...string) FrameFilter {
+	return func(f Frame) bool {
+		return !hasAnyPrefix(f.Package, prefixes)
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterFrames applies the filters to each frame and caps the result to maxFrames,
+// if non-zero. The number of frames dropped due to the cap is also returned.
+func filterFrames(frames []Frame, filters []FrameFilter, maxFrames uint) ([]Frame, int) {
+	retained := make([]Frame, 0, len(frames))
+	for _, f := range frames {
+		if isOwnFrame(f) || !applyFilters(f, filters) {
+			continue
+		}
+		retained = append(retained, f)
+	}
+	if maxFrames == 0 || uint(len(retained)) <= maxFrames {
+		return retained, 0
+	}
+	return retained[:maxFrames], len(retained) - int(maxFrames)
+}
+
+func applyFilters(f Frame, filters []FrameFilter) bool {
+	for _, filter := range filters {
+		if !filter(f) {
+			return false
+		}
+	}
+	return true
+}
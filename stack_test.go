@@ -0,0 +1,176 @@
+package errorcontext
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrame_String(t *testing.T) {
+	t.Parallel()
+
+	f := Frame{Function: "main.main", File: "/app/main.go", Line: 5}
+	assert.Equal(t, "main.main\n\t/app/main.go:5", f.String())
+
+	b, err := json.Marshal(f)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"func":"main.main","file":"/app/main.go","line":5}`, string(b))
+}
+
+func TestPackageName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		function string
+		want     string
+	}{
+		{function: "main.main", want: "main"},
+		{function: "runtime.gopanic", want: "runtime"},
+		{function: "runtime/debug.Stack", want: "runtime/debug"},
+		{
+			function: "github.com/georgepsarakis/errorcontext.Recoverer[...].Wrap.func1",
+			want:     "github.com/georgepsarakis/errorcontext",
+		},
+		{
+			function: "github.com/georgepsarakis/errorcontext/backend/zap.(*Error).MarkAsPanic",
+			want:     "github.com/georgepsarakis/errorcontext/backend/zap",
+		},
+		{function: "gopkg.in/yaml%2ev3.(*decoder).unmarshal", want: "gopkg.in/yaml.v3"},
+		{function: "gopkg.in/yaml.v3.(*decoder).unmarshal", want: "gopkg.in/yaml.v3"},
+		{function: "gopkg.in/yaml.v3.Marshal", want: "gopkg.in/yaml.v3"},
+		{function: "github.com/acme/app/v2.main.func1", want: "github.com/acme/app/v2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			assert.Equal(t, tt.want, packageName(tt.function))
+		})
+	}
+}
+
+func TestCallers(t *testing.T) {
+	t.Parallel()
+
+	frames := callers(0)
+	require.NotEmpty(t, frames)
+	assert.Equal(t, "github.com/georgepsarakis/errorcontext.TestCallers", frames[0].Function)
+	assert.Equal(t, "github.com/georgepsarakis/errorcontext", frames[0].Package)
+	assert.Contains(t, frames[0].File, "stack_test.go")
+	assert.NotZero(t, frames[0].Line)
+	assert.NotZero(t, frames[0].PC)
+}
+
+func TestFrameFilters(t *testing.T) {
+	t.Parallel()
+
+	frames := []Frame{
+		{Function: "runtime.gopanic", Package: "runtime", File: "/go/src/runtime/panic.go"},
+		{Function: "runtime.sigpanic", Package: "runtime", File: "/go/src/runtime/signal_unix.go"},
+		{Function: "runtime/debug.Stack", Package: "runtime/debug", File: "/go/src/runtime/debug/stack.go"},
+		{
+			Function: "github.com/georgepsarakis/errorcontext.Recoverer[...].Wrap",
+			Package:  "github.com/georgepsarakis/errorcontext",
+			File:     "/src/errorcontext/errorcontext.go",
+		},
+		{
+			Function: "github.com/georgepsarakis/errorcontext/backend/zap.FromPanic",
+			Package:  "github.com/georgepsarakis/errorcontext/backend/zap",
+			File:     "/src/errorcontext/backend/zap/zap.go",
+		},
+		{
+			Function: "github.com/georgepsarakis/errorcontext/httpmw.(*Middleware).Handler.func1",
+			Package:  "github.com/georgepsarakis/errorcontext/httpmw",
+			File:     "/src/errorcontext/httpmw/httpmw.go",
+		},
+		{
+			Function: "github.com/georgepsarakis/errorcontext-extras.Helper",
+			Package:  "github.com/georgepsarakis/errorcontext-extras",
+			File:     "/src/errorcontext-extras/extras.go",
+		},
+		{Function: "github.com/acme/app/db.Query", Package: "github.com/acme/app/db", File: "/src/app/db/db.go"},
+		{Function: "github.com/acme/app.main", Package: "github.com/acme/app", File: "/src/app/main.go"},
+		{Function: "net/http.HandlerFunc.ServeHTTP", Package: "net/http", File: "/go/src/net/http/server.go"},
+		{Function: "runtime.goexit", Package: "runtime", File: "/go/src/runtime/asm_amd64.s"},
+	}
+	functions := func(frames []Frame) []string {
+		var fns []string
+		for _, f := range frames {
+			fns = append(fns, f.Function)
+		}
+		return fns
+	}
+	tests := []struct {
+		name       string
+		filters    []FrameFilter
+		maxFrames  uint
+		want       []string
+		wantElided int
+	}{
+		{
+			name: "drops own module frames without filters",
+			want: []string{
+				"runtime.gopanic",
+				"runtime.sigpanic",
+				"runtime/debug.Stack",
+				"github.com/georgepsarakis/errorcontext-extras.Helper",
+				"github.com/acme/app/db.Query",
+				"github.com/acme/app.main",
+				"net/http.HandlerFunc.ServeHTTP",
+				"runtime.goexit",
+			},
+		},
+		{
+			name:    "drops runtime panic frames",
+			filters: []FrameFilter{DropRuntimeFrames},
+			want: []string{
+				"github.com/georgepsarakis/errorcontext-extras.Helper",
+				"github.com/acme/app/db.Query",
+				"github.com/acme/app.main",
+				"net/http.HandlerFunc.ServeHTTP",
+				"runtime.goexit",
+			},
+		},
+		{
+			name:    "includes package prefixes",
+			filters: []FrameFilter{IncludePackages("github.com/acme/")},
+			want: []string{
+				"github.com/acme/app/db.Query",
+				"github.com/acme/app.main",
+			},
+		},
+		{
+			name:    "excludes package prefixes",
+			filters: []FrameFilter{DropRuntimeFrames, ExcludePackages("net/", "runtime", "github.com/georgepsarakis/")},
+			want: []string{
+				"github.com/acme/app/db.Query",
+				"github.com/acme/app.main",
+			},
+		},
+		{
+			name:       "caps the depth",
+			filters:    []FrameFilter{DropRuntimeFrames},
+			maxFrames:  1,
+			want:       []string{"github.com/georgepsarakis/errorcontext-extras.Helper"},
+			wantElided: 4,
+		},
+		{
+			name:      "does not elide frames within the cap",
+			filters:   []FrameFilter{IncludePackages("github.com/acme/")},
+			maxFrames: 2,
+			want: []string{
+				"github.com/acme/app/db.Query",
+				"github.com/acme/app.main",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, elided := filterFrames(frames, tt.filters, tt.maxFrames)
+			assert.Equal(t, tt.want, functions(got))
+			assert.Equal(t, tt.wantElided, elided)
+		})
+	}
+}